@@ -2,7 +2,8 @@
 // Use of this source code is governed under the Apache License, Version 2.0
 // that can be found in the LICENSE file.
 
-// gohci-check checks a project configuration.
+// gohci-check checks a project configuration, or a worker configuration with
+// -worker-config.
 package main
 
 import (
@@ -17,7 +18,22 @@ import (
 )
 
 func mainImpl() error {
+	workerConfig := flag.String("worker-config", "", "worker configuration file to check instead, e.g. gohci.yml")
 	flag.Parse()
+	if *workerConfig != "" {
+		if flag.NArg() != 0 {
+			return errors.New("-worker-config doesn't take an argument")
+		}
+		b, err := os.ReadFile(*workerConfig)
+		if err != nil {
+			return err
+		}
+		warnings, err := checkWorkerConfig(b)
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "gohci-check: warning: %s.\n", w)
+		}
+		return err
+	}
 	f := ""
 	var err error
 	switch flag.NArg() {
@@ -0,0 +1,95 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"periph.io/x/gohci"
+)
+
+// checkWorkerConfig validates a worker's gohci.yml.
+//
+// It returns an error for settings gohci-worker would reject, per
+// gohci.WorkerConfig.Validate, or that can't work, and warnings for the
+// insecure ones.
+func checkWorkerConfig(b []byte) ([]string, error) {
+	c := gohci.DefaultWorkerConfig()
+	// Detect a missing version.
+	c.Version = 0
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(c); err != nil {
+		return nil, err
+	}
+	if c.Version > gohci.WorkerConfigVersion || c.Version < 0 {
+		return nil, fmt.Errorf("unsupported version %d", c.Version)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	// gohci-worker generates a longer one.
+	if len(c.WebHookSecret) < 16 {
+		return nil, errors.New("webhooksecret must be at least 16 characters")
+	}
+	if c.Oauth2AccessToken == "" && len(c.Oauth2AccessTokens) == 0 {
+		return nil, errors.New("oauth2accesstoken is required")
+	}
+	for _, t := range append([]string{c.Oauth2AccessToken, c.GistToken}, c.Oauth2AccessTokens...) {
+		if t != "" && !isValidToken(t) {
+			return nil, fmt.Errorf("invalid OAuth2 token %q; get one at https://github.com/settings/tokens", redact(t))
+		}
+	}
+
+	var w []string
+	if c.Version == 0 {
+		w = append(w, "version is missing; gohci-worker migrates the file on startup")
+	}
+	if len(c.AllowedOrgs) == 0 && len(c.AllowedRepos) == 0 && !c.RejectUnknownRepos {
+		w = append(w, "allowedorgs and allowedrepos are empty and rejectunknownrepos is false; any repository with the webhook secret can run code on this worker")
+	}
+	if len(c.DashboardUsers) == 0 {
+		w = append(w, "dashboardusers is empty; the dashboard and the jobs' output are public")
+	}
+	if c.TrustCollaborators {
+		w = append(w, "trustcollaborators runs the PRs of every collaborator without restriction")
+	}
+	for _, r := range c.RestrictedCommands {
+		switch r {
+		case "bash", "make", "node", "perl", "python", "python3", "sh", "zsh":
+			w = append(w, fmt.Sprintf("restrictedcommands %q lets untrusted PRs run arbitrary code", r))
+		}
+	}
+	for _, u := range []struct{ name, value string }{{"coordinatorurl", c.CoordinatorURL}, {"advertiseurl", c.AdvertiseURL}, {"relayurl", c.RelayURL}} {
+		if strings.HasPrefix(u.value, "http://") {
+			w = append(w, fmt.Sprintf("%s %q is not encrypted; use https://", u.name, u.value))
+		}
+	}
+	return w, nil
+}
+
+// isValidToken returns true if t looks like a GitHub token, either a classic
+// 40 hex characters one or a prefixed one.
+func isValidToken(t string) bool {
+	for _, p := range []string{"ghp_", "gho_", "ghu_", "ghs_", "github_pat_"} {
+		if strings.HasPrefix(t, p) {
+			return len(t) > len(p)+20
+		}
+	}
+	return len(t) == 40 && strings.Trim(t, "0123456789abcdef") == ""
+}
+
+// redact returns the start of the token, enough to recognize it without
+// leaking it.
+func redact(t string) string {
+	if len(t) > 8 {
+		return t[:8] + "…"
+	}
+	return t
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckWorkerConfig(t *testing.T) {
+	const base = "version: 1\nname: w\nwebhooksecret: 0123456789abcdef\noauth2accesstoken: ghp_0123456789abcdefghijklmnop\n"
+	w, err := checkWorkerConfig([]byte(base + "allowedorgs: [periph]\ndashboardusers: [\"a:b\"]\n"))
+	if err != nil || len(w) != 0 {
+		t.Fatal(w, err)
+	}
+	w, err = checkWorkerConfig([]byte(base + "restrictedcommands: [go, sh]\nrelayurl: http://example.com\n"))
+	if err != nil || len(w) != 4 {
+		t.Fatal(w, err)
+	}
+	data := []struct {
+		in  string
+		err string
+	}{
+		{base + "port: 70000\n", "invalid port"},
+		{base + "unknown: 1\n", "not found"},
+		{"name: w\nwebhooksecret: short\noauth2accesstoken: ghp_0123456789abcdefghijklmnop\n", "webhooksecret"},
+		{"name: w\nwebhooksecret: 0123456789abcdef\noauth2accesstoken: Get one at https://github.com/settings/tokens\n", "invalid OAuth2 token"},
+		{"webhooksecret: 0123456789abcdef\noauth2accesstoken: ghp_0123456789abcdefghijklmnop\n", "name"},
+		// Validated the same way as gohci-worker.
+		{base + "warmrepos: [periph/gohci]\nwarmat: 25:00\n", "invalid warmat"},
+		{base + "nice: 20\n", "invalid nice"},
+		{base + "projects: [{name: periph}]\n", "invalid projects name"},
+		{base + "gitremotes: [{name: lab/fw}]\n", "invalid gitremotes entry"},
+		{base + "coordinator: true\ncoordinatorurl: https://c\n", "mutually exclusive"},
+	}
+	for i, l := range data {
+		if _, err := checkWorkerConfig([]byte(l.in)); err == nil || !strings.Contains(err.Error(), l.err) {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestIsValidToken(t *testing.T) {
+	for _, s := range []string{"0123456789abcdef0123456789abcdef01234567", "github_pat_0123456789abcdefghijklmnop"} {
+		if !isValidToken(s) {
+			t.Fatal(s)
+		}
+	}
+	for _, s := range []string{"", "ghp_", "0123456789ABCDEF0123456789abcdef01234567", "hello"} {
+		if isValidToken(s) {
+			t.Fatal(s)
+		}
+	}
+}
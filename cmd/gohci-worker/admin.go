@@ -0,0 +1,103 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// serveJobAction handles the authenticated POST /api/v1/jobs/<id>/cancel and
+// /api/v1/jobs/<id>/retry.
+func (s *server) serveJobAction(w http.ResponseWriter, r *http.Request, id int64, action string) {
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	switch action {
+	case "cancel":
+		if !s.w.cancel(id, "canceled") {
+			http.Error(w, "Job not pending nor running", http.StatusNotFound)
+			return
+		}
+	case "retry":
+		if err := s.w.retry(id); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	logServer.Info("job action", "job_id", id, "action", action)
+	writeJSON(w, struct{}{})
+}
+
+// adminRequest sends an authenticated request to the worker running locally
+// and returns the response body.
+func adminRequest(port int, secret, method, path string) ([]byte, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf("http://localhost:%d/api/v1/%s", port, path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// listJobs prints the jobs of the worker running locally, most recent first.
+func listJobs(port int, secret string) error {
+	b, err := adminRequest(port, secret, "GET", "jobs")
+	if err != nil {
+		return err
+	}
+	var jobs []jobRecord
+	if err = json.Unmarshal(b, &jobs); err != nil {
+		return err
+	}
+	t := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "ID\tSTATE\tREPO\tCOMMIT\tPR\tSTARTED\tDURATION")
+	for _, j := range jobs {
+		pr := ""
+		if j.PullID != 0 {
+			pr = fmt.Sprintf("#%d", j.PullID)
+		}
+		c := j.Commit
+		if len(c) > 12 {
+			c = c[:12]
+		}
+		fmt.Fprintf(t, "%d\t%s\t%s/%s\t%s\t%s\t%s\t%s\n", j.ID, j.State, j.Org, j.Repo, c, pr, j.Started.Format(time.DateTime), roundDuration(j.Duration))
+	}
+	return t.Flush()
+}
+
+// jobAction asks the worker running locally to cancel or retry a job.
+func jobAction(port int, secret string, id int64, action string) error {
+	if _, err := adminRequest(port, secret, "POST", fmt.Sprintf("jobs/%d/%s", id, action)); err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// serveAPI handles the requests under /api/v1/.
+func (s *server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	p := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/")
+	if s.w == nil && (p[0] == "jobs" || p[0] == "trigger" || p[0] == "pause" || p[0] == "resume" || p[0] == "drain" || p[0] == "selftest") {
+		// A coordinator doesn't run jobs.
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case len(p) == 1 && p[0] == "workers":
+		s.serveWorkers(w, r)
+	case len(p) == 1 && p[0] == "dispatch":
+		s.serveDispatch(w, r)
+	case len(p) == 1 && p[0] == "selftest":
+		s.serveSelfTest(w, r)
+	case len(p) == 1 && p[0] == "trigger":
+		s.serveTrigger(w, r)
+	case len(p) == 1 && (p[0] == "pause" || p[0] == "resume" || p[0] == "drain"):
+		s.servePause(w, r, p[0])
+	case len(p) == 1 && p[0] == "jobs":
+		if r.Method != "GET" {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireView(w, r) {
+			return
+		}
+		q := r.URL.Query()
+		f := jobFilter{Org: q.Get("org"), Repo: q.Get("repo"), State: q.Get("state"), Limit: 100}
+		if v := q.Get("limit"); v != "" {
+			var err error
+			if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		jobs := s.w.jobs(f)
+		if jobs == nil {
+			jobs = []jobRecord{}
+		}
+		writeJSON(w, jobs)
+	case len(p) == 2 && p[0] == "jobs":
+		id, err := strconv.ParseInt(p[1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid job ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method != "GET" {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireView(w, r) {
+			return
+		}
+		j, ok := s.w.job(id)
+		if !ok {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, j)
+	case len(p) >= 1 && p[0] == "deliveries":
+		s.serveDeliveries(w, r, p)
+	case len(p) == 3 && p[0] == "jobs" && (p[2] == "cancel" || p[2] == "retry"):
+		id, err := strconv.ParseInt(p[1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid job ID", http.StatusBadRequest)
+			return
+		}
+		s.serveJobAction(w, r, id, p[2])
+	case len(p) == 3 && p[0] == "jobs" && p[2] == "stream":
+		id, err := strconv.ParseInt(p[1], 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid job ID", http.StatusBadRequest)
+			return
+		}
+		if r.Method != "GET" {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireView(w, r) {
+			return
+		}
+		s.serveStream(w, r, id)
+	default:
+		logServer.Warn("unexpected path", "path", r.URL.Path)
+		http.NotFound(w, r)
+	}
+}
+
+// writeJSON writes v as the JSON response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	if err := e.Encode(v); err != nil {
+		logServer.Error("failed to encode response", "err", err)
+	}
+}
+
+// serveStream streams the output of a job as Server-Sent Events.
+//
+// Each output line is sent as a "data" message. A final "end" event is sent
+// once the job completed.
+func (s *server) serveStream(w http.ResponseWriter, r *http.Request, id int64) {
+	l := s.w.stream(id)
+	if l == nil {
+		http.Error(w, "Job not found or already completed", http.StatusNotFound)
+		return
+	}
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	backlog, ch, cancel := l.subscribe()
+	defer cancel()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disable buffering in nginx based proxies.
+	w.Header().Set("X-Accel-Buffering", "no")
+	for _, line := range backlog {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	f.Flush()
+	for {
+		select {
+		case line, ok := <-ch:
+			if !ok {
+				// The subscriber may have been dropped for being too slow, in which
+				// case the client is expected to reconnect.
+				if l.done() {
+					fmt.Fprint(w, "event: end\ndata: \n\n")
+					f.Flush()
+				}
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			f.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// azureVersion is the Blob service REST API version used, for both the
+// requests and the SAS.
+const azureVersion = "2020-12-06"
+
+// azureClient is a blobStore in an Azure Blob Storage container, with the
+// storage account's shared key.
+//
+// It only uses the REST API and service SAS so it doesn't need the Azure SDK.
+type azureClient struct {
+	account   string
+	container string
+	key       []byte
+	endpoint  string // https://<account>.blob.core.windows.net, overridden in tests.
+	expiry    time.Duration
+	client    *http.Client
+}
+
+// newAzureClient returns a client for the container. key is the base64
+// encoded storage account key.
+func newAzureClient(account, container, key string, expiry time.Duration) (*azureClient, error) {
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azurekey: %w", err)
+	}
+	return &azureClient{
+		account:   account,
+		container: container,
+		key:       k,
+		endpoint:  "https://" + account + ".blob.core.windows.net",
+		expiry:    expiry,
+		client:    &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// upload implements blobStore.
+func (a *azureClient) upload(ctx context.Context, name, contentType, content string) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", a.endpoint+a.path(name), strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	return a.do(req)
+}
+
+// remove implements blobStore.
+func (a *azureClient) remove(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", a.endpoint+a.path(name), nil)
+	if err != nil {
+		return err
+	}
+	return a.do(req)
+}
+
+// signedURL implements blobStore with a read-only service SAS.
+//
+// https://learn.microsoft.com/rest/api/storageservices/create-service-sas
+func (a *azureClient) signedURL(name string, now time.Time) (string, error) {
+	se := now.UTC().Add(a.expiry).Format("2006-01-02T15:04:05Z")
+	resource := "/blob/" + a.account + "/" + a.container + "/" + name
+	// sp, st, se, canonicalizedResource, si, sip, spr, sv, sr, snapshot, ses,
+	// rscc, rscd, rsce, rscl, rsct.
+	s := strings.Join([]string{"r", "", se, resource, "", "", "https", azureVersion, "b", "", "", "", "", "", "", ""}, "\n")
+	q := url.Values{
+		"sp":  {"r"},
+		"se":  {se},
+		"spr": {"https"},
+		"sv":  {azureVersion},
+		"sr":  {"b"},
+		"sig": {a.sign(s)},
+	}
+	return "https://" + a.account + ".blob.core.windows.net" + a.path(name) + "?" + q.Encode(), nil
+}
+
+// String implements blobStore.
+func (a *azureClient) String() string {
+	return "https://" + a.account + ".blob.core.windows.net/" + a.container
+}
+
+// path returns the escaped path of the blob.
+func (a *azureClient) path(name string) string {
+	return "/" + a.container + "/" + uriEscape(name, true)
+}
+
+// do signs the request with the shared key and sends it.
+//
+// https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key
+func (a *azureClient) do(req *http.Request) error {
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureVersion)
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var headers []string
+	for k := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			headers = append(headers, k+":"+req.Header.Get(k)+"\n")
+		}
+	}
+	sort.Strings(headers)
+	// VERB, Content-Encoding, Content-Language, Content-Length, Content-MD5,
+	// Content-Type, Date, If-Modified-Since, If-Match, If-None-Match,
+	// If-Unmodified-Since, Range.
+	s := strings.Join([]string{req.Method, "", "", length, "", req.Header.Get("Content-Type"), "", "", "", "", "", ""}, "\n") +
+		"\n" + strings.Join(headers, "") + "/" + a.account + req.URL.EscapedPath()
+	req.Header.Set("Authorization", "SharedKey "+a.account+":"+a.sign(s))
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+func (a *azureClient) sign(s string) string {
+	h := hmac.New(sha256.New, a.key)
+	_, _ = h.Write([]byte(s))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
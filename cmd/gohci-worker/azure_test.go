@@ -0,0 +1,93 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAzure(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("secret"))
+	a, err := newAzureClient("acct", "logs", key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(s string) string {
+		h := hmac.New(sha256.New, []byte("secret"))
+		_, _ = h.Write([]byte(s))
+		return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+	blobs := map[string]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		length := ""
+		if len(b) != 0 {
+			length = "11"
+		}
+		s := r.Method + "\n\n\n" + length + "\n\n" + r.Header.Get("Content-Type") + "\n\n\n\n\n\n\n"
+		if r.Method == "PUT" {
+			s += "x-ms-blob-type:BlockBlob\n"
+		}
+		s += "x-ms-date:" + r.Header.Get("x-ms-date") + "\nx-ms-version:2020-12-06\n/acct" + r.URL.EscapedPath()
+		if got := r.Header.Get("Authorization"); got != "SharedKey acct:"+sign(s) {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			blobs[r.URL.Path] = string(b)
+			w.WriteHeader(http.StatusCreated)
+		case "DELETE":
+			delete(blobs, r.URL.Path)
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer ts.Close()
+	a.endpoint = ts.URL
+
+	ctx := context.Background()
+	if err = a.upload(ctx, "p/cmd1 go", "text/plain; charset=utf-8", "hello world"); err != nil {
+		t.Fatal(err)
+	}
+	if blobs["/logs/p/cmd1 go"] != "hello world" {
+		t.Fatal(blobs)
+	}
+	if err = a.remove(ctx, "p/cmd1 go"); err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 0 {
+		t.Fatal(blobs)
+	}
+
+	u, err := a.signedURL("p/a b", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Host != "acct.blob.core.windows.net" || p.EscapedPath() != "/logs/p/a%20b" {
+		t.Fatal(u)
+	}
+	q := p.Query()
+	if q.Get("sp") != "r" || q.Get("se") != "2026-01-02T04:04:05Z" || q.Get("sr") != "b" {
+		t.Fatal(u)
+	}
+	want := sign("r\n\n2026-01-02T04:04:05Z\n/blob/acct/logs/p/a b\n\n\nhttps\n2020-12-06\nb\n" + strings.Repeat("\n", 6))
+	if q.Get("sig") != want {
+		t.Fatal(u)
+	}
+}
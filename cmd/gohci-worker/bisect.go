@@ -0,0 +1,94 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"periph.io/x/gohci"
+)
+
+// lastGood returns the commit of the job preceding id on the same ref if it
+// passed, "" otherwise. jobs are sorted most recent first.
+func lastGood(jobs []jobRecord, id int64, ref string) string {
+	for _, r := range jobs {
+		if r.ID >= id || r.Ref != ref || r.PullID != 0 {
+			continue
+		}
+		switch r.State {
+		case "success":
+			return r.Commit
+		case "failure":
+			// It was already broken.
+			return ""
+		}
+	}
+	return ""
+}
+
+// bisect finds the first bad commit between good and the job's commit with
+// "git bisect run", using the check c. It must be run on the checkout done by
+// checkout().
+//
+// The check is run from the repository's root. j.firstBad is set on success.
+//
+// When signing keys are configured, all the commits being bisected must be
+// signed since the check runs on each of them.
+func (j *jobRequest) bisect(good string, c gohci.Check) (string, bool) {
+	cmd, err := checkCmd(&c)
+	if err != nil {
+		return err.Error() + "\n", false
+	}
+	p := filepath.Join("src", j.getPath())
+	// The checkout is shallow, fetch the history. The mirror never is.
+	fetch := []string{"git", "fetch", "--quiet", "--unshallow", "origin", j.commitHash}
+	if j.mirror != "" {
+		fetch = []string{"git", "fetch", "--quiet", "origin", j.commitHash}
+	}
+	out := ""
+	ok := true
+	runAll := func(cmds ...[]string) {
+		for _, cmd := range cmds {
+			stdout, ok2 := j.run(p, c.Env, cmd, true)
+			out += stdout
+			if ok = ok2; !ok {
+				return
+			}
+		}
+	}
+	runAll(fetch, []string{"git", "merge-base", "--is-ancestor", good, j.commitHash})
+	if ok && len(j.signingKeys) != 0 {
+		if stdout, err := verifyCommits(filepath.Join(j.gopath, p), good, j.commitHash, j.signingKeys); err != nil {
+			out += stdout + "Not bisecting: " + err.Error() + "\n"
+			ok = false
+		}
+	}
+	if ok {
+		runAll(
+			// The checks may have modified the tracked files.
+			[]string{"git", "reset", "--quiet", "--hard"},
+			[]string{"git", "bisect", "start", j.commitHash, good},
+			append([]string{"git", "bisect", "run"}, cmd...))
+	}
+	if ok {
+		if j.firstBad = parseFirstBad(out); j.firstBad == "" {
+			ok = false
+		}
+	}
+	stdout, _ := j.run(p, nil, []string{"git", "bisect", "reset"}, false)
+	return out + stdout, ok
+}
+
+// parseFirstBad returns the commit reported by "git bisect run" as the first
+// bad one.
+func parseFirstBad(out string) string {
+	for _, l := range strings.Split(out, "\n") {
+		if strings.HasSuffix(l, " is the first bad commit") {
+			return strings.TrimSuffix(l, " is the first bad commit")
+		}
+	}
+	return ""
+}
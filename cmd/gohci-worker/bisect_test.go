@@ -0,0 +1,57 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestLastGood(t *testing.T) {
+	jobs := []jobRecord{
+		{ID: 5, Ref: "refs/heads/main", State: "failure", Commit: "e"},
+		{ID: 4, Ref: "refs/heads/main", State: "skipped", Commit: "d"},
+		{ID: 3, Ref: "refs/heads/dev", State: "success", Commit: "c"},
+		{ID: 2, Ref: "refs/heads/main", State: "success", Commit: "b"},
+		{ID: 1, Ref: "refs/heads/main", State: "failure", Commit: "a"},
+	}
+	if got := lastGood(jobs, 5, "refs/heads/main"); got != "b" {
+		t.Fatalf("got %q", got)
+	}
+	if got := lastGood(jobs, 6, "refs/heads/main"); got != "" {
+		t.Fatalf("already broken: got %q", got)
+	}
+	if got := lastGood(jobs, 2, "refs/heads/main"); got != "" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestParseFirstBad(t *testing.T) {
+	out := "running 'go' 'test'\nabc123 is the first bad commit\ncommit abc123\n"
+	if got := parseFirstBad(out); got != "abc123" {
+		t.Fatalf("got %q", got)
+	}
+	if got := parseFirstBad("nope"); got != "" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestBisectUnsigned(t *testing.T) {
+	origin, commits := newTestOrigin(t)
+	j := newJobRequest(jobSpec{org: "lab", repo: "fw", remoteURL: "file://" + origin, commitHash: commits[1], local: true}, t.TempDir())
+	if out, ok := j.checkout(); !ok {
+		t.Fatalf("checkout failed:\n%s", out)
+	}
+	j.signingKeys = []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKh6zKlHkXvJ1wYwQ0bD0dH9lX4m0n8lYz2v3s0Yc6rL"}
+	out, ok := j.bisect(commits[0], gohci.Check{Cmd: []string{"false"}})
+	if ok || !strings.Contains(out, "Not bisecting: "+commits[1]+": commit is not signed") {
+		t.Fatalf("expected refusal:\n%s", out)
+	}
+	if strings.Contains(out, "bisect run") {
+		t.Fatalf("the check ran:\n%s", out)
+	}
+}
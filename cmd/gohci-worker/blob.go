@@ -0,0 +1,164 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// blobIndexFile is the object listing the files of a job, linked from the
+// commit status.
+const blobIndexFile = "index.html"
+
+// blobStore is a cloud storage bucket holding the output of the jobs instead
+// of the gists.
+type blobStore interface {
+	// upload creates or replaces an object.
+	upload(ctx context.Context, name, contentType, content string) error
+	// remove deletes an object.
+	remove(ctx context.Context, name string) error
+	// signedURL returns a URL to read the object without authentication until
+	// it expires.
+	signedURL(name string, now time.Time) (string, error)
+	// String returns the URL of the bucket.
+	String() string
+}
+
+// uriEscape percent-encodes everything but the RFC 3986 unreserved
+// characters, and the slashes if keepSlash is true.
+func uriEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// blobName returns the name of a job's file in the store. The gist file names
+// can contain slashes, e.g. "cmd1 go test ./...".
+func blobName(prefix, name string) string {
+	return prefix + strings.Replace(name, "/", "_", -1)
+}
+
+// blobCreate uploads the initial files of a job to the store. The returned
+// gist is not created on GitHub; its ID is the prefix of the objects and its
+// URL is the signed URL of the index, if any.
+//
+// The prefix contains the job ID, so retries of the same commit don't collide.
+func (w *workerQueue) blobCreate(j *jobRequest, g *github.Gist) (*github.Gist, error) {
+	prefix := fmt.Sprintf("%s/%s/%s-%d/", j.org, j.repo, j.commitHash, j.id)
+	for n, f := range g.Files {
+		if err := w.blobs.upload(w.ctx, blobName(prefix, string(n)), "text/plain; charset=utf-8", f.GetContent()); err != nil {
+			return nil, err
+		}
+	}
+	u, err := w.blobs.signedURL(blobName(prefix, blobIndexFile), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	out := &github.Gist{
+		ID:          github.String(prefix),
+		Description: g.Description,
+		Files:       map[github.GistFilename]github.GistFile{},
+	}
+	if u != "" {
+		out.HTMLURL = &u
+	}
+	for n := range g.Files {
+		out.Files[n] = github.GistFile{}
+	}
+	return out, w.blobIndex(j, newGistOutput(out))
+}
+
+// blobFlush uploads the pending files to the store and updates the index.
+//
+// It is the equivalent of gist(), there's no limit on the number of files.
+func (w *workerQueue) blobFlush(j *jobRequest, o *gistOutput) bool {
+	ok := true
+	prefix := o.primary.GetID()
+	for _, f := range o.pending {
+		if err := w.blobs.upload(w.ctx, blobName(prefix, f.name), "text/plain; charset=utf-8", f.content); err != nil {
+			j.log.Error("failed to upload the output", "err", err)
+			ok = false
+			continue
+		}
+		o.primary.Files[github.GistFilename(f.name)] = github.GistFile{}
+		o.dirty = true
+	}
+	o.pending = nil
+	if o.dirty {
+		if err := w.blobIndex(j, o); err != nil {
+			j.log.Error("failed to upload the output", "err", err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// blobPartial replaces the running file in the store, or deletes it when
+// content is empty.
+func (w *workerQueue) blobPartial(j *jobRequest, o *gistOutput, content string) {
+	name := blobName(o.primary.GetID(), gistRunningFile)
+	var err error
+	if content != "" {
+		if err = w.blobs.upload(w.ctx, name, "text/plain; charset=utf-8", content); err == nil {
+			o.primary.Files[gistRunningFile] = github.GistFile{}
+		}
+	} else if _, ok := o.primary.Files[gistRunningFile]; ok {
+		if err = w.blobs.remove(w.ctx, name); err == nil {
+			delete(o.primary.Files, gistRunningFile)
+		}
+	} else {
+		return
+	}
+	if err == nil {
+		err = w.blobIndex(j, o)
+	}
+	if err != nil {
+		j.log.Error("failed to update the partial output", "err", err)
+	}
+}
+
+// blobIndex uploads the HTML page linking to the job's files, with the current
+// description.
+func (w *workerQueue) blobIndex(j *jobRequest, o *gistOutput) error {
+	names := make([]string, 0, len(o.primary.Files))
+	for n := range o.primary.Files {
+		names = append(names, string(n))
+	}
+	sort.Strings(names)
+	now := time.Now()
+	d := html.EscapeString(o.description())
+	b := "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + d + "</title></head><body>\n<h1>" + d + "</h1>\n<ul>\n"
+	for _, n := range names {
+		u, err := w.blobs.signedURL(blobName(o.primary.GetID(), n), now)
+		if err != nil {
+			return err
+		}
+		if u == "" {
+			// The index is in the same directory.
+			u = uriEscape(blobName("", n), false)
+		}
+		b += "<li><a href=\"" + html.EscapeString(u) + "\">" + html.EscapeString(n) + "</a></li>\n"
+	}
+	b += "</ul>\n</body></html>\n"
+	if err := w.blobs.upload(w.ctx, blobName(o.primary.GetID(), blobIndexFile), "text/html; charset=utf-8", b); err != nil {
+		return err
+	}
+	o.dirty = false
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// boardModel returns the board model from the device tree under root, e.g.
+// "Raspberry Pi 4 Model B Rev 1.4", or "" if not available.
+//
+// The device tree is only present on ARM and RISC-V boards.
+func boardModel(root string) string {
+	/* #nosec G304 */
+	b, err := os.ReadFile(filepath.Join(root, "proc", "device-tree", "model"))
+	if err != nil {
+		return ""
+	}
+	// The string is NUL terminated.
+	return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+}
+
+// osRelease returns the distribution name from os-release under root, e.g.
+// "Debian GNU/Linux 12 (bookworm)", or "" if not available.
+//
+// https://www.freedesktop.org/software/systemd/man/os-release.html
+func osRelease(root string) string {
+	/* #nosec G304 */
+	b, err := os.ReadFile(filepath.Join(root, "etc", "os-release"))
+	if err != nil {
+		return ""
+	}
+	vars := map[string]string{}
+	for _, l := range strings.Split(string(b), "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(l), "=")
+		if !ok || strings.HasPrefix(k, "#") {
+			continue
+		}
+		if u, err := strconv.Unquote(v); err == nil {
+			v = u
+		} else {
+			v = strings.Trim(v, `'"`)
+		}
+		vars[k] = v
+	}
+	if s := vars["PRETTY_NAME"]; s != "" {
+		return s
+	}
+	return strings.TrimSpace(vars["NAME"] + " " + vars["VERSION"])
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBoard(t *testing.T) {
+	root := t.TempDir()
+	if s := boardModel(root); s != "" {
+		t.Fatal(s)
+	}
+	if s := osRelease(root); s != "" {
+		t.Fatal(s)
+	}
+	write := func(p, c string) {
+		p = filepath.Join(root, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(c), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("proc/device-tree/model", "Raspberry Pi 4 Model B Rev 1.4\x00")
+	if s := boardModel(root); s != "Raspberry Pi 4 Model B Rev 1.4" {
+		t.Fatalf("%q", s)
+	}
+	write("etc/os-release", "# comment\nNAME=\"Debian GNU/Linux\"\nVERSION='12 (bookworm)'\n")
+	if s := osRelease(root); s != "Debian GNU/Linux 12 (bookworm)" {
+		t.Fatalf("%q", s)
+	}
+	write("etc/os-release", "PRETTY_NAME=\"Raspbian GNU/Linux 11 (bullseye)\"\nNAME=\"Raspbian GNU/Linux\"\n")
+	if s := osRelease(root); s != "Raspbian GNU/Linux 11 (bullseye)" {
+		t.Fatalf("%q", s)
+	}
+}
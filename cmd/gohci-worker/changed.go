@@ -0,0 +1,135 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// prFiles returns the files modified by the PR.
+func (w *workerQueue) prFiles(org, repo string, pullID int) ([]string, error) {
+	var out []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := w.client.PullRequests.ListFiles(w.ctx, org, repo, pullID, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			out = append(out, f.GetFilename())
+			if f.GetPreviousFilename() != "" {
+				out = append(out, f.GetPreviousFilename())
+			}
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// changedPackages sets j.packages to the packages affected by the files
+// modified, per the packages listed by the go tool in the checkout. The checks
+// are run on all the packages when it can't be determined.
+func (j *jobRequest) changedPackages(files []string) (string, bool) {
+	root := filepath.Join(j.gopath, "src", j.getPath())
+	/* #nosec G204 */
+	c := exec.Command("go", "list", "-e", "-f", "{{.ImportPath}} {{.Dir}} {{join .Deps \" \"}}", "./...")
+	c.Dir = root
+	c.Env = j.env
+	b, err := c.Output()
+	if err != nil {
+		return fmt.Sprintf("go list failed, running all the packages: %v\n", err), true
+	}
+	pkgs, full := affectedPackages(string(b), root, files)
+	if full {
+		return "Running all the packages\n", true
+	}
+	j.packages = pkgs
+	j.onlyPackages = true
+	if len(pkgs) == 0 {
+		return "No package affected\n", true
+	}
+	return "Affected packages:\n  " + strings.Join(pkgs, "\n  ") + "\n", true
+}
+
+// affectedPackages returns the packages containing one of the files or
+// depending on one of these packages.
+//
+// list is the output of go list with ImportPath, Dir and Deps on each line.
+// files are relative to root with forward slashes. full is true when a file
+// affects all the packages, e.g. go.mod.
+func affectedPackages(list, root string, files []string) ([]string, bool) {
+	type pkg struct {
+		path string
+		deps []string
+	}
+	byDir := map[string]string{}
+	var pkgs []pkg
+	for _, l := range strings.Split(strings.TrimSpace(list), "\n") {
+		f := strings.Fields(l)
+		if len(f) < 2 {
+			continue
+		}
+		pkgs = append(pkgs, pkg{f[0], f[2:]})
+		if rel, err := filepath.Rel(root, f[1]); err == nil {
+			byDir[filepath.ToSlash(rel)] = f[0]
+		}
+	}
+	changed := map[string]bool{}
+	for _, f := range files {
+		switch path.Base(f) {
+		case "go.mod", "go.sum", "go.work", ".gohci.yml":
+			return nil, true
+		}
+		// Files in testdata or in a sub directory without Go files belong to
+		// the closest package.
+		for d := path.Dir(f); ; d = path.Dir(d) {
+			if p, ok := byDir[d]; ok {
+				changed[p] = true
+				break
+			}
+			if d == "." {
+				break
+			}
+		}
+	}
+	var out []string
+	for _, p := range pkgs {
+		affected := changed[p.path]
+		for _, d := range p.deps {
+			affected = affected || changed[d]
+		}
+		if affected {
+			out = append(out, p.path)
+		}
+	}
+	sort.Strings(out)
+	return out, false
+}
+
+// withPackages returns cmd with "./..." replaced by the packages. It returns
+// false if the command is about "./..." and there's no package to test.
+func withPackages(cmd, pkgs []string) ([]string, bool) {
+	var out []string
+	for _, a := range cmd {
+		if a != "./..." {
+			out = append(out, a)
+			continue
+		}
+		if len(pkgs) == 0 {
+			return nil, false
+		}
+		out = append(out, pkgs...)
+	}
+	return out, true
+}
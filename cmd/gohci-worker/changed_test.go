@@ -0,0 +1,51 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAffectedPackages(t *testing.T) {
+	root := filepath.Join("/w", "src", "example.com", "m")
+	list := "example.com/m " + root + " fmt\n" +
+		"example.com/m/spi " + filepath.Join(root, "spi") + " example.com/m fmt\n" +
+		"example.com/m/spi/spitest " + filepath.Join(root, "spi", "spitest") + " example.com/m example.com/m/spi\n" +
+		"example.com/m/i2c " + filepath.Join(root, "i2c") + " fmt\n"
+	data := []struct {
+		files []string
+		want  []string
+		full  bool
+	}{
+		{[]string{"README.md"}, []string{"example.com/m", "example.com/m/spi", "example.com/m/spi/spitest"}, false},
+		{[]string{"spi/spi.go"}, []string{"example.com/m/spi", "example.com/m/spi/spitest"}, false},
+		{[]string{"i2c/testdata/dump.bin"}, []string{"example.com/m/i2c"}, false},
+		{[]string{"i2c/i2c.go", "spi/spitest/fake.go"}, []string{"example.com/m/i2c", "example.com/m/spi/spitest"}, false},
+		{[]string{"i2c/doc/index.md"}, []string{"example.com/m/i2c"}, false},
+		{[]string{"spi/spi.go", "go.mod"}, nil, true},
+		{[]string{".gohci.yml"}, nil, true},
+	}
+	for i, l := range data {
+		got, full := affectedPackages(list, root, l.files)
+		if full != l.full || !reflect.DeepEqual(got, l.want) {
+			t.Fatalf("#%d: %q %t != %q %t", i, got, full, l.want, l.full)
+		}
+	}
+}
+
+func TestWithPackages(t *testing.T) {
+	got, ok := withPackages([]string{"go", "test", "-race", "./..."}, []string{"a", "b"})
+	if want := []string{"go", "test", "-race", "a", "b"}; !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("%q", got)
+	}
+	if _, ok := withPackages([]string{"go", "test", "./..."}, nil); ok {
+		t.Fatal("expected skip")
+	}
+	if got, ok := withPackages([]string{"make"}, nil); !ok || !reflect.DeepEqual(got, []string{"make"}) {
+		t.Fatalf("%q", got)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"periph.io/x/gohci/internal/valid"
+)
+
+// command is a command addressed to gohci in a comment.
+type command struct {
+	name string // "run" or "cancel".
+	ref  string // Branch or tag to run on, if specified.
+}
+
+// parseCommand parses a comment. It returns nil if the comment is not
+// addressed to gohci.
+//
+// The supported formats are:
+//   - "gohci"
+//   - "gohci: run"
+//   - "gohci: run branch=<name>", "gohci: run tag=<name>" or
+//     "gohci: run ref=<refs/...>"
+//   - "gohci: cancel"
+func parseCommand(body string) (*command, error) {
+	body = strings.TrimSpace(body)
+	if body == "gohci" {
+		return &command{name: "run"}, nil
+	}
+	if !strings.HasPrefix(body, "gohci:") {
+		return nil, nil
+	}
+	f := strings.Fields(body[len("gohci:"):])
+	if len(f) == 0 {
+		return nil, fmt.Errorf("missing command")
+	}
+	c := &command{name: f[0]}
+	switch c.name {
+	case "run":
+	case "cancel":
+		if len(f) != 1 {
+			return nil, fmt.Errorf("cancel takes no argument")
+		}
+		return c, nil
+	default:
+		return nil, fmt.Errorf("unknown command %q", c.name)
+	}
+	for _, a := range f[1:] {
+		kv := strings.SplitN(a, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid argument %q", a)
+		}
+		if c.ref != "" {
+			return nil, fmt.Errorf("only one of branch, tag or ref can be specified")
+		}
+		switch kv[0] {
+		case "branch", "tag":
+			if strings.HasPrefix(kv[1], "refs/") {
+				return nil, fmt.Errorf("%s must not start with refs/, use ref= instead", kv[0])
+			}
+		case "ref":
+			if !strings.HasPrefix(kv[1], "refs/") {
+				return nil, fmt.Errorf("ref must start with refs/")
+			}
+		default:
+			return nil, fmt.Errorf("unknown argument %q", kv[0])
+		}
+		if !valid.Ref(kv[1]) {
+			return nil, fmt.Errorf("invalid %s %q", kv[0], kv[1])
+		}
+		c.ref = kv[1]
+	}
+	return c, nil
+}
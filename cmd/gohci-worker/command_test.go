@@ -0,0 +1,64 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	data := []struct {
+		in   string
+		name string
+		ref  string
+	}{
+		{"gohci", "run", ""},
+		{" gohci\n", "run", ""},
+		{"gohci: run", "run", ""},
+		{"gohci: run branch=release-1.2", "run", "release-1.2"},
+		{"gohci: run tag=v1.0.0", "run", "v1.0.0"},
+		{"gohci: run ref=refs/heads/foo", "run", "refs/heads/foo"},
+		{"gohci: cancel", "cancel", ""},
+	}
+	for _, l := range data {
+		c, err := parseCommand(l.in)
+		if err != nil {
+			t.Fatalf("parseCommand(%q) failed: %v", l.in, err)
+		}
+		if c == nil || c.name != l.name || c.ref != l.ref {
+			t.Fatalf("parseCommand(%q) = %#v", l.in, c)
+		}
+	}
+	for _, in := range []string{"LGTM", "gohci is great", ""} {
+		if c, err := parseCommand(in); c != nil || err != nil {
+			t.Fatalf("parseCommand(%q) = %#v, %v", in, c, err)
+		}
+	}
+	for _, in := range []string{"gohci:", "gohci: fly", "gohci: run branch=", "gohci: run branch=a..b", "gohci: run tag=-x", "gohci: run ref=heads/x", "gohci: run branch=a tag=b", "gohci: run foo=bar", "gohci: cancel now"} {
+		if _, err := parseCommand(in); err == nil {
+			t.Fatalf("parseCommand(%q) should have failed", in)
+		}
+	}
+}
+
+func TestFindRef(t *testing.T) {
+	out := "aaa\tHEAD\nbbb\trefs/heads/main\nccc\trefs/heads/release-1.2\nddd\trefs/tags/v1\neee\trefs/tags/v1^{}\nfff\trefs/pull/3/head\n"
+	data := []struct {
+		pullID   int
+		ref      string
+		expected string
+	}{
+		{0, "", "aaa"},
+		{3, "", "fff"},
+		{0, "release-1.2", "ccc"},
+		{0, "v1", "eee"},
+		{0, "refs/heads/main", "bbb"},
+		{0, "nope", ""},
+		{4, "", ""},
+	}
+	for _, l := range data {
+		if h := findRef(out, l.pullID, l.ref); h != l.expected {
+			t.Fatalf("findRef(%d, %q) = %q; not %q", l.pullID, l.ref, h, l.expected)
+		}
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"log"
+	"io"
+	"net/url"
 	"os"
+	"path"
 	"runtime"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 	"periph.io/x/gohci"
@@ -20,44 +23,157 @@ import (
 // loadConfig loads the current config or returns the default one.
 //
 // It saves a reformatted version on disk if it was not in the canonical format.
+// An older layout is migrated and saved, keeping the original as
+// "<fileName>.v<version>". When fileName is read-only, e.g. mounted from a
+// Kubernetes ConfigMap, the migrated config is only used in memory.
 func loadConfig(fileName string) (*gohci.WorkerConfig, error) {
 	// Create a dummy config file to make it easier to edit.
-	c := &gohci.WorkerConfig{
-		Port:              8080,
-		Oauth2AccessToken: "Get one at https://github.com/settings/tokens",
-	}
+	c := gohci.DefaultWorkerConfig()
 	/* #nosec G304 */
 	b, err := os.ReadFile(fileName)
 	if err != nil {
-		log.Printf("Failed to read: %s", err)
+		logMain.Warn("failed to read config", "err", err)
 		return nil, rewrite(fileName, c)
 	}
-	if err = yaml.Unmarshal(b, c); err != nil {
-		log.Printf("Failed to decode %s: %s", fileName, err)
-		_ = rewrite(fileName, c)
-		return nil, err
+	m, v, err := migrateConfig(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if v != gohci.WorkerConfigVersion {
+		if err = saveMigrated(fileName, b, m, v); err == nil {
+			logMain.Warn("migrated config", "file", fileName, "from", v, "to", gohci.WorkerConfigVersion)
+		} else if isReadOnly(err) {
+			logMain.Warn("migrated config in memory only; update the file", "file", fileName, "from", v, "to", gohci.WorkerConfigVersion, "err", err)
+		} else {
+			return nil, err
+		}
+	}
+	// Unknown keys are refused instead of silently ignored, as they are
+	// usually typos or settings that were removed.
+	d := yaml.NewDecoder(bytes.NewReader(m))
+	d.KnownFields(true)
+	if err = d.Decode(c); err != nil && err != io.EOF {
+		// Don't rewrite the file, the settings in error would be lost.
+		return nil, fmt.Errorf("failed to decode %s: %w", fileName, err)
 	}
 	if c.Name == "" || c.WebHookSecret == "" {
-		log.Printf("Unconfigured %s: rewriting", fileName)
-		return nil, rewrite(fileName, c)
+		// Names the missing key.
+		missing := c.Validate()
+		logMain.Warn("unconfigured, rewriting", "file", fileName)
+		if err = rewrite(fileName, c); isReadOnly(err) {
+			// It has to be fixed at the source, e.g. the Kubernetes ConfigMap.
+			return nil, fmt.Errorf("%s: %w", fileName, missing)
+		}
+		return nil, err
+	}
+	if err = c.Validate(); err != nil {
+		return nil, err
 	}
 	return c, nil
 }
 
+// applyProxy exports the proxy settings as environment variables.
+//
+// This way they apply both to the GitHub client, via
+// http.ProxyFromEnvironment, and to the child processes like git. It must be
+// called before the first HTTP request.
+func applyProxy(c *gohci.WorkerConfig) error {
+	for _, v := range []struct{ name, value string }{{"HTTP_PROXY", c.HTTPProxy}, {"HTTPS_PROXY", c.HTTPSProxy}, {"NO_PROXY", c.NoProxy}} {
+		if v.value == "" {
+			continue
+		}
+		if v.name != "NO_PROXY" {
+			if u, err := url.Parse(v.value); err != nil || u.Host == "" {
+				return fmt.Errorf("invalid %s %q", strings.ToLower(strings.Replace(v.name, "_", "", 1)), v.value)
+			}
+		}
+		// Some tools only look at the lower case version.
+		if err := os.Setenv(v.name, v.value); err != nil {
+			return err
+		}
+		if err := os.Setenv(strings.ToLower(v.name), v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isAllowedRepo returns true if the worker accepts jobs for org/repo per
+// AllowedOrgs and AllowedRepos.
+func isAllowedRepo(c *gohci.WorkerConfig, org, repo string) bool {
+	if c.RejectUnknownRepos && findProject(c, org, repo) == nil {
+		return false
+	}
+	if len(c.AllowedOrgs) == 0 && len(c.AllowedRepos) == 0 {
+		return true
+	}
+	for _, o := range c.AllowedOrgs {
+		if strings.EqualFold(o, org) {
+			return true
+		}
+	}
+	for _, r := range c.AllowedRepos {
+		if strings.EqualFold(r, org+"/"+repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// findProject returns the first project matching org/repo, or nil.
+func findProject(c *gohci.WorkerConfig, org, repo string) *gohci.Project {
+	n := strings.ToLower(org + "/" + repo)
+	for i := range c.Projects {
+		if ok, _ := path.Match(strings.ToLower(c.Projects[i].Name), n); ok {
+			return &c.Projects[i]
+		}
+	}
+	return nil
+}
+
+// saveMigrated keeps the original config b of version v and replaces fileName
+// with the migrated config m.
+func saveMigrated(fileName string, b, m []byte, v int) error {
+	if err := os.WriteFile(fmt.Sprintf("%s.v%d", fileName, v), b, 0o600); err != nil {
+		return err
+	}
+	// Makes it editable in notepad.exe.
+	if runtime.GOOS == "windows" {
+		m = bytes.Replace(m, []byte("\n"), []byte("\r\n"), -1)
+	}
+	return os.WriteFile(fileName, m, 0o600)
+}
+
 func rewrite(fileName string, c *gohci.WorkerConfig) error {
 	// Defer these since they require actual work.
 	if c.WebHookSecret == "" {
-		var b [32]byte
-		if _, err := rand.Read(b[:]); err != nil {
+		var err error
+		if c.WebHookSecret, err = newWebhookSecret(); err != nil {
 			return err
 		}
-		c.WebHookSecret = base64.RawURLEncoding.EncodeToString(b[:])
 	}
 	if c.Name == "" {
 		if c.Name, _ = os.Hostname(); c.Name == "" {
 			c.Name = "gohci"
 		}
 	}
+	if err := writeConfig(fileName, c); err != nil {
+		return err
+	}
+	return fmt.Errorf("wrote new %s", fileName)
+}
+
+// newWebhookSecret returns a random secret for the webhook.
+func newWebhookSecret() (string, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b[:]), nil
+}
+
+// writeConfig saves the worker configuration.
+func writeConfig(fileName string, c *gohci.WorkerConfig) error {
 	b, err := yaml.Marshal(c)
 	if err != nil {
 		return err
@@ -66,10 +182,7 @@ func rewrite(fileName string, c *gohci.WorkerConfig) error {
 	if runtime.GOOS == "windows" {
 		b = bytes.Replace(b, []byte("\n"), []byte("\r\n"), -1)
 	}
-	if err = os.WriteFile(fileName, b, 0600); err != nil {
-		return err
-	}
-	return fmt.Errorf("wrote new %s", fileName)
+	return os.WriteFile(fileName, b, 0600)
 }
 
 func loadProjectConfig(fileName string) *gohci.ProjectConfig {
@@ -82,6 +195,6 @@ func loadProjectConfig(fileName string) *gohci.ProjectConfig {
 			return p
 		}
 	}
-	log.Printf("Failed to load %s: %s", fileName, err)
+	logJob.Warn("failed to load project config", "file", fileName, "err", err)
 	return nil
 }
@@ -0,0 +1,140 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestIsAllowedRepo(t *testing.T) {
+	if !isAllowedRepo(&gohci.WorkerConfig{}, "foo", "bar") {
+		t.Fatal("empty lists should allow everything")
+	}
+	c := &gohci.WorkerConfig{AllowedOrgs: []string{"Periph"}, AllowedRepos: []string{"maruel/panicparse"}}
+	data := []struct {
+		org, repo string
+		expected  bool
+	}{
+		{"periph", "gohci", true},
+		{"maruel", "PanicParse", true},
+		{"maruel", "other", false},
+		{"evil", "gohci", false},
+	}
+	for _, l := range data {
+		if got := isAllowedRepo(c, l.org, l.repo); got != l.expected {
+			t.Fatalf("isAllowedRepo(%q, %q) = %t", l.org, l.repo, got)
+		}
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	c := &gohci.WorkerConfig{Projects: []gohci.Project{{Name: "periph/gohci"}, {Name: "Periph/*"}}}
+	if p := findProject(c, "periph", "gohci"); p != &c.Projects[0] {
+		t.Fatal(p)
+	}
+	if p := findProject(c, "periph", "Host"); p != &c.Projects[1] {
+		t.Fatal(p)
+	}
+	if p := findProject(c, "maruel", "gohci"); p != nil {
+		t.Fatal(p)
+	}
+	if !isAllowedRepo(c, "maruel", "gohci") {
+		t.Fatal("projects alone don't restrict")
+	}
+	c.RejectUnknownRepos = true
+	if isAllowedRepo(c, "maruel", "gohci") || !isAllowedRepo(c, "periph", "host") {
+		t.Fatal("rejectunknownrepos")
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	in := "# The port.\nport: 8081\nallowedrepos:\n- a/b\nprojects:\n- name: periph/gohci\n- name: periph/host\n"
+	out, v, err := migrateConfig([]byte(in))
+	if err != nil || v != 0 {
+		t.Fatal(v, err)
+	}
+	want := "version: 1\n# The port.\nport: 8081\nallowedrepos:\n    - a/b\n    - periph/gohci\n    - periph/host\n"
+	if string(out) != want {
+		t.Fatalf("%q", out)
+	}
+	if out2, v, err := migrateConfig(out); err != nil || v != 1 || string(out2) != string(out) {
+		t.Fatal(v, err)
+	}
+	// The projects with checks keep them, overriding the repository's.
+	in = "projects:\n- name: periph/gohci\n  checks:\n  - cmd: [go, test]\n- name: periph/host\n"
+	if out, v, err = migrateConfig([]byte(in)); err != nil || v != 0 {
+		t.Fatal(v, err)
+	}
+	want = "version: 1\nallowedrepos:\n    - periph/gohci\n    - periph/host\nprojects:\n    - name: periph/gohci\n      checks:\n        - cmd: [go, test]\n      checksmode: override\n"
+	if string(out) != want {
+		t.Fatalf("%q", out)
+	}
+	for _, s := range []string{
+		"version: 2\n",
+		"version: -1\n",
+		"projects: 1\n",
+		"- a\n",
+	} {
+		if _, _, err := migrateConfig([]byte(s)); err == nil {
+			t.Fatalf("%q: expected error", s)
+		}
+	}
+}
+
+func TestLoadConfigUnknownField(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "gohci.yml")
+	b := []byte("version: 1\nname: w\nwebhooksecret: s\nunknown: 1\n")
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(p); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Fatal(err)
+	}
+	// The file must not have been rewritten.
+	if got, _ := os.ReadFile(p); string(got) != string(b) {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestLoadConfigReadOnlyUnconfigured(t *testing.T) {
+	if runtime.GOOS != "windows" && os.Getuid() == 0 {
+		t.Skip("root can write to a read-only file")
+	}
+	p := filepath.Join(t.TempDir(), "gohci.yml")
+	b := []byte("version: 1\nname: w\n")
+	if err := os.WriteFile(p, b, 0o400); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(p); err == nil || err.Error() != p+": webhooksecret is required" {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadConfigMigrate(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "gohci.yml")
+	b := []byte("name: w\nwebhooksecret: s\nprojects:\n- name: periph/gohci\n- name: periph/host\n  checks:\n  - cmd: [go, test]\n")
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version != 1 || len(c.AllowedRepos) != 2 || c.AllowedRepos[0] != "periph/gohci" {
+		t.Fatalf("%+v", c)
+	}
+	if len(c.Projects) != 1 || c.Projects[0].Name != "periph/host" || c.Projects[0].ChecksMode != "override" || len(c.Projects[0].Checks) != 1 {
+		t.Fatalf("%+v", c.Projects)
+	}
+	if got, _ := os.ReadFile(p + ".v0"); string(got) != string(b) {
+		t.Fatalf("%q", got)
+	}
+}
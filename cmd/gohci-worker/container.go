@@ -0,0 +1,84 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted on Linux.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// containerRuntime returns the container runtime the worker runs in, or "".
+func containerRuntime() string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return "kubernetes"
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return "docker"
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return "podman"
+	}
+	return ""
+}
+
+// cgroupLimits returns the CPU and memory limits of the cgroup mounted at
+// root, either v2 or v1. They are 0 when unlimited or unknown.
+//
+// These are the effective resources of the checks in a container, which can
+// be much lower than the host's.
+func cgroupLimits(root string) (float64, int64) {
+	read := func(p string) string {
+		/* #nosec G304 */
+		b, err := os.ReadFile(filepath.Join(root, p))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	cpus := 0.
+	var mem int64
+	if s := read("cpu.max"); s != "" {
+		// v2: "<quota> <period>" or "max <period>".
+		if f := strings.Fields(s); len(f) == 2 {
+			cpus = cpuQuota(f[0], f[1])
+		}
+		mem, _ = strconv.ParseInt(read("memory.max"), 10, 64)
+	} else {
+		cpus = cpuQuota(read("cpu/cpu.cfs_quota_us"), read("cpu/cpu.cfs_period_us"))
+		mem, _ = strconv.ParseInt(read("memory/memory.limit_in_bytes"), 10, 64)
+		// v1 reports a huge page aligned value when unlimited.
+		if mem >= 1<<62 {
+			mem = 0
+		}
+	}
+	if mem < 0 {
+		mem = 0
+	}
+	return cpus, mem
+}
+
+// cpuQuota returns quota/period, or 0 when unlimited.
+func cpuQuota(quota, period string) float64 {
+	q, err1 := strconv.ParseInt(quota, 10, 64)
+	p, err2 := strconv.ParseInt(period, 10, 64)
+	if err1 != nil || err2 != nil || q <= 0 || p <= 0 {
+		return 0
+	}
+	return float64(q) / float64(p)
+}
+
+// isReadOnly returns true if err is due to a read-only file or filesystem,
+// e.g. a Kubernetes ConfigMap.
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS) || errors.Is(err, fs.ErrPermission)
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCgroupLimits(t *testing.T) {
+	data := []struct {
+		name  string
+		files map[string]string
+		cpus  float64
+		mem   int64
+	}{
+		{"none", nil, 0, 0},
+		{
+			"v2",
+			map[string]string{"cpu.max": "150000 100000\n", "memory.max": "536870912\n"},
+			1.5, 512 << 20,
+		},
+		{
+			"v2 unlimited",
+			map[string]string{"cpu.max": "max 100000\n", "memory.max": "max\n"},
+			0, 0,
+		},
+		{
+			"v1",
+			map[string]string{
+				"cpu/cpu.cfs_quota_us":         "200000\n",
+				"cpu/cpu.cfs_period_us":        "100000\n",
+				"memory/memory.limit_in_bytes": "1073741824\n",
+			},
+			2, 1 << 30,
+		},
+		{
+			"v1 unlimited",
+			map[string]string{
+				"cpu/cpu.cfs_quota_us":         "-1\n",
+				"cpu/cpu.cfs_period_us":        "100000\n",
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+			},
+			0, 0,
+		},
+	}
+	for _, l := range data {
+		t.Run(l.name, func(t *testing.T) {
+			root := t.TempDir()
+			for n, c := range l.files {
+				p := filepath.Join(root, filepath.FromSlash(n))
+				if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(p, []byte(c), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if cpus, mem := cgroupLimits(root); cpus != l.cpus || mem != l.mem {
+				t.Fatalf("got %g, %d; want %g, %d", cpus, mem, l.cpus, l.mem)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// heartbeat is how often a worker registers itself with its coordinator. A
+// worker not seen for 3 heartbeats is dropped from the registry.
+const heartbeat = time.Minute
+
+// workerInfo is a worker as registered with a coordinator.
+type workerInfo struct {
+	Name         string
+	URL          string // URL at which the coordinator reaches the worker.
+	Labels       []string
+	AllowedOrgs  []string
+	AllowedRepos []string
+	Load         int  // Pending and running jobs.
+	Paused       bool // The worker refuses new jobs.
+	LastSeen     time.Time
+}
+
+// registry is the list of workers known to a coordinator.
+//
+// The coordinator receives the GitHub webhook once and forwards each delivery
+// to one registered worker serving the repository, with the labels required by
+// the coordinator's project, preferably an idle one. The worker then handles
+// it as if it had received it directly, including queuing it when busy and
+// reporting its own commit status.
+type registry struct {
+	c      *gohci.WorkerConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	workers map[string]*workerInfo
+}
+
+func newRegistry(c *gohci.WorkerConfig) *registry {
+	return &registry{
+		c:       c,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		workers: map[string]*workerInfo{},
+	}
+}
+
+// register adds or refreshes a worker.
+func (r *registry) register(w workerInfo) {
+	w.LastSeen = time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.workers[w.Name]; !ok {
+		logServer.Info("worker registered", "worker", w.Name, "url", w.URL, "labels", w.Labels)
+	}
+	r.workers[w.Name] = &w
+}
+
+// list returns the live workers sorted by name, dropping the stale ones.
+func (r *registry) list() []workerInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]workerInfo, 0, len(r.workers))
+	for n, w := range r.workers {
+		if time.Since(w.LastSeen) > 3*heartbeat {
+			logServer.Warn("worker lost", "worker", n)
+			delete(r.workers, n)
+			continue
+		}
+		out = append(out, *w)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// dispatch forwards a delivery to a worker serving its repository.
+//
+// The worker must have the labels required by the coordinator's project for
+// the repository. The least loaded one is used, so the job runs on an idle
+// worker if there's one, otherwise it is queued on the worker with the
+// shortest queue. The pings and the cancel commands are sent to all of them.
+func (r *registry) dispatch(d *webhookDelivery) {
+	org, repo := deliveryRepo(d.Payload)
+	b, err := json.Marshal(d)
+	if err != nil {
+		logServer.Error("failed to encode delivery", "guid", d.GUID, "err", err)
+		return
+	}
+	var requires []string
+	if p := findProject(r.c, org, repo); p != nil {
+		requires = p.Requires
+	}
+	var targets []workerInfo
+	for _, w := range r.list() {
+		if org != "" && !isAllowedRepo(&gohci.WorkerConfig{AllowedOrgs: w.AllowedOrgs, AllowedRepos: w.AllowedRepos}, org, repo) {
+			continue
+		}
+		if len(missingLabels(requires, w.Labels)) != 0 {
+			continue
+		}
+		targets = append(targets, w)
+	}
+	if !isBroadcast(d) {
+		targets = r.pick(targets)
+	}
+	if len(targets) == 0 {
+		logServer.Warn("no worker to dispatch to", "guid", d.GUID, "event", d.Event, "repo", org+"/"+repo, "requires", requires)
+		return
+	}
+	for _, w := range targets {
+		go func(w workerInfo) {
+			if err := r.post(w.URL+"/api/v1/dispatch", b); err != nil {
+				logServer.Error("failed to dispatch", "worker", w.Name, "guid", d.GUID, "err", err)
+			}
+		}(w)
+	}
+	logServer.Info("dispatched", "guid", d.GUID, "event", d.Event, "repo", org+"/"+repo, "workers", len(targets), "to", targets[0].Name)
+}
+
+// pick returns the least loaded of the workers, preferring the ones not
+// paused, and accounts for the job until its next heartbeat.
+func (r *registry) pick(workers []workerInfo) []workerInfo {
+	if len(workers) == 0 {
+		return nil
+	}
+	best := workers[0]
+	for _, w := range workers[1:] {
+		if (best.Paused && !w.Paused) || (best.Paused == w.Paused && w.Load < best.Load) {
+			best = w
+		}
+	}
+	r.mu.Lock()
+	if w := r.workers[best.Name]; w != nil {
+		w.Load++
+	}
+	r.mu.Unlock()
+	return []workerInfo{best}
+}
+
+// isBroadcast returns true if the delivery must be sent to all the workers,
+// as it doesn't start a job: a ping or a "gohci: cancel" comment, which
+// applies to the worker running the PR's job.
+func isBroadcast(d *webhookDelivery) bool {
+	switch d.Event {
+	case "ping":
+		return true
+	case "commit_comment", "issue_comment", "pull_request_review_comment":
+		var p struct {
+			Comment struct {
+				Body string
+			}
+		}
+		if json.Unmarshal(d.Payload, &p) != nil {
+			return false
+		}
+		c, _ := parseCommand(p.Comment.Body)
+		return c != nil && c.name == "cancel"
+	}
+	return false
+}
+
+// post sends an authenticated JSON request.
+func (r *registry) post(u string, b []byte) error {
+	return postJSON(r.client, u, r.c.WebHookSecret, b)
+}
+
+// deliveryRepo returns the repository of a webhook payload, if any.
+func deliveryRepo(payload []byte) (string, string) {
+	var p struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+		}
+	}
+	if json.Unmarshal(payload, &p) != nil {
+		return "", ""
+	}
+	parts := strings.SplitN(p.Repository.FullName, "/", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// postJSON posts b to u with the secret as a bearer token.
+func postJSON(client *http.Client, u, secret string, b []byte) error {
+	req, err := http.NewRequest("POST", u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// registerLoop registers the worker with its coordinator until the process
+// exits. Each heartbeat reports the worker's load.
+func registerLoop(c *gohci.WorkerConfig, wkr worker) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	info := workerInfo{
+		Name: c.Name,
+		URL:  strings.TrimSuffix(c.AdvertiseURL, "/"),
+		// The same labels as the checks' requires.
+		Labels:       append([]string{runtime.GOOS, runtime.GOARCH}, c.Labels...),
+		AllowedOrgs:  c.AllowedOrgs,
+		AllowedRepos: c.AllowedRepos,
+	}
+	u := strings.TrimSuffix(c.CoordinatorURL, "/") + "/api/v1/workers"
+	registered := false
+	for {
+		info.Load = wkr.load()
+		info.Paused = wkr.isPaused()
+		b, _ := json.Marshal(&info)
+		if err := postJSON(client, u, c.WebHookSecret, b); err != nil {
+			logServer.Error("failed to register with coordinator", "url", u, "err", err)
+			registered = false
+		} else if !registered {
+			logServer.Info("registered with coordinator", "url", u)
+			registered = true
+		}
+		time.Sleep(heartbeat)
+	}
+}
+
+// serveWorkers handles /api/v1/workers on a coordinator.
+func (s *server) serveWorkers(w http.ResponseWriter, r *http.Request) {
+	if s.reg == nil {
+		http.Error(w, "Not a coordinator", http.StatusNotFound)
+		return
+	}
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case "GET":
+		writeJSON(w, s.reg.list())
+	case "POST":
+		var wi workerInfo
+		if err := json.NewDecoder(r.Body).Decode(&wi); err != nil || wi.Name == "" || wi.URL == "" {
+			http.Error(w, "Invalid worker", http.StatusBadRequest)
+			return
+		}
+		s.reg.register(wi)
+		writeJSON(w, struct{}{})
+	default:
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveDispatch handles /api/v1/dispatch on a worker, which receives the
+// deliveries forwarded by its coordinator.
+func (s *server) serveDispatch(w http.ResponseWriter, r *http.Request) {
+	if s.w == nil {
+		http.Error(w, "Not a worker", http.StatusNotFound)
+		return
+	}
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	var d webhookDelivery
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, "Invalid delivery", http.StatusBadRequest)
+		return
+	}
+	if s.c.WebhookMaxDeliveries > 0 {
+		s.h.addDelivery(&d, s.c.WebhookMaxDeliveries)
+	}
+	if d.GUID != "" && !s.recent.add("delivery/"+d.GUID) {
+		logServer.Info("ignoring redelivery", "guid", d.GUID)
+	} else if err := s.handleDelivery(&d, true); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, struct{}{})
+}
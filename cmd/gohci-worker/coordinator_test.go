@@ -0,0 +1,97 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+func TestRegistryDispatch(t *testing.T) {
+	got := make(chan string, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d webhookDelivery
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			t.Error(err)
+		}
+		got <- r.URL.Path + " " + r.Header.Get("Authorization") + " " + d.GUID
+	}))
+	defer ts.Close()
+
+	c := &gohci.WorkerConfig{WebHookSecret: "secret"}
+	r := newRegistry(c)
+	r.register(workerInfo{Name: "a", URL: ts.URL + "/a", AllowedOrgs: []string{"periph"}})
+	r.register(workerInfo{Name: "b", URL: ts.URL + "/b", AllowedRepos: []string{"other/repo"}})
+	r.register(workerInfo{Name: "c", URL: ts.URL + "/c"})
+	r.register(workerInfo{Name: "d", URL: ts.URL + "/d", Labels: []string{"rpi"}, Load: 1})
+	r.register(workerInfo{Name: "e", URL: ts.URL + "/e", Paused: true})
+	r.workers["c"].LastSeen = time.Now().Add(-time.Hour)
+	if l := r.list(); len(l) != 4 || l[0].Name != "a" || l[1].Name != "b" || l[2].Name != "d" || l[3].Name != "e" {
+		t.Fatalf("%#v", l)
+	}
+	push := func(guid string) *webhookDelivery {
+		return &webhookDelivery{GUID: guid, Event: "push", Payload: []byte(`{"repository":{"full_name":"periph/gohci"}}`)}
+	}
+	// The idle worker first, then the least loaded one; a job is accounted for
+	// until the next heartbeat.
+	for i, want := range []string{"/a", "/a", "/d"} {
+		r.dispatch(push(strconv.Itoa(i)))
+		if g := <-got; g != want+"/api/v1/dispatch Bearer secret "+strconv.Itoa(i) {
+			t.Fatalf("#%d: %s", i, g)
+		}
+	}
+	// The project requires a label.
+	c.Projects = []gohci.Project{{Name: "periph/*", Requires: []string{"rpi"}}}
+	r.dispatch(push("3"))
+	if g := <-got; g != "/d/api/v1/dispatch Bearer secret 3" {
+		t.Fatal(g)
+	}
+	// A cancel command goes to all of them.
+	r.dispatch(&webhookDelivery{GUID: "4", Event: "issue_comment", Payload: []byte(`{"repository":{"full_name":"other/repo"},"comment":{"body":"gohci: cancel"}}`)})
+	var all []string
+	for i := 0; i < 3; i++ {
+		all = append(all, strings.Fields(<-got)[0])
+	}
+	sort.Strings(all)
+	if strings.Join(all, ",") != "/b/api/v1/dispatch,/d/api/v1/dispatch,/e/api/v1/dispatch" {
+		t.Fatal(all)
+	}
+	select {
+	case g := <-got:
+		t.Fatal(g)
+	default:
+	}
+}
+
+func TestReplayCoordinator(t *testing.T) {
+	var wg sync.WaitGroup
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer wg.Done()
+		got = r.URL.Path
+	}))
+	defer ts.Close()
+	r := newRegistry(&gohci.WorkerConfig{WebHookSecret: "secret"})
+	r.register(workerInfo{Name: "a", URL: ts.URL + "/a"})
+	// There's no local worker to run it.
+	s := &server{reg: r}
+	wg.Add(1)
+	if err := s.replay(&webhookDelivery{GUID: "1", Event: "push", Payload: []byte(`{"repository":{"full_name":"periph/gohci"}}`)}); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	if got != "/a/api/v1/dispatch" {
+		t.Fatal(got)
+	}
+}
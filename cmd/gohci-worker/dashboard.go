@@ -0,0 +1,78 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// dashboardTmpl is the HTML page listing the recent jobs.
+//
+// It refreshes itself every minute so it can be left on a wall display.
+var dashboardTmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"round": roundDuration,
+	"short": shortHash,
+	"ago": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="60">
+<link rel="alternate" type="application/atom+xml" href="feed.atom">
+<title>gohci - {{.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.3em; text-align: left; vertical-align: top; }
+.success { background-color: #cfc; }
+.failure { background-color: #fcc; }
+.pending, .running { background-color: #ffc; }
+.check { display: inline-block; margin: 0.1em; padding: 0 0.3em; border-radius: 3px; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>Up for {{.Uptime}}, built with {{.Version}}.</p>
+<table>
+<tr><th>#</th><th>Repository</th><th>Commit</th><th>Started</th><th>Duration</th><th>State</th><th>Checks</th></tr>
+{{- range .Jobs}}
+<tr class="{{.State}}">
+<td>{{.ID}}</td>
+<td><a href="https://github.com/{{.Org}}/{{.Repo}}">{{.Org}}/{{.Repo}}</a></td>
+<td>{{if .PullID}}<a href="https://github.com/{{.Org}}/{{.Repo}}/pull/{{.PullID}}">#{{.PullID}}</a> {{end}}<a href="https://github.com/{{.Org}}/{{.Repo}}/commit/{{.Commit}}">{{short .Commit}}</a></td>
+<td>{{ago .Started}} ago</td>
+<td>{{if .Duration}}{{round .Duration}}{{end}}</td>
+<td>{{if .GistURL}}<a href="{{.GistURL}}">{{.State}}</a>{{else}}{{.State}}{{end}}{{if or (eq .State "pending") (eq .State "running")}} <a href="api/v1/jobs/{{.ID}}/stream">live</a>{{end}}{{if .Note}} ({{.Note}}){{end}}</td>
+<td>{{range .Checks}}<span class="check {{if .Success}}success{{else}}failure{{end}}">{{.Name}} {{round .Duration}}</span>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// serveDashboard renders the recent jobs as an HTML page.
+func (s *server) serveDashboard(w http.ResponseWriter) {
+	data := struct {
+		Name    string
+		Uptime  time.Duration
+		Version string
+		Jobs    []jobRecord
+	}{
+		Name:    s.c.Name,
+		Uptime:  time.Since(s.start).Round(time.Second),
+		Version: runtime.Version(),
+		Jobs:    s.w.jobs(jobFilter{Limit: 50}),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTmpl.Execute(w, data); err != nil {
+		logServer.Error("failed to render dashboard", "err", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// recentSet remembers keys for a limited amount of time.
+//
+// It is used to ignore GitHub redeliveries and the same event sent by two
+// webhooks, e.g. one on the repository and one on the organization, which
+// would otherwise burn device time running the same job twice.
+type recentSet struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func newRecentSet(ttl time.Duration) *recentSet {
+	return &recentSet{ttl: ttl, seen: map[string]time.Time{}}
+}
+
+// add returns false if the key was already added within ttl.
+func (r *recentSet) add(key string) bool {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, t := range r.seen {
+		if now.Sub(t) > r.ttl {
+			delete(r.seen, k)
+		}
+	}
+	if _, ok := r.seen[key]; ok {
+		return false
+	}
+	r.seen[key] = now
+	return true
+}
+
+// hookKey returns a key identifying what an event would trigger, independent
+// of the delivery. It returns "" for events that are not deduplicated.
+func hookKey(event interface{}) string {
+	switch e := event.(type) {
+	case *github.CommitCommentEvent:
+		return fmt.Sprintf("commit_comment/%s/%d", e.GetRepo().GetFullName(), e.GetComment().GetID())
+	case *github.IssueCommentEvent:
+		return fmt.Sprintf("issue_comment/%s/%d/%s", e.GetRepo().GetFullName(), e.GetComment().GetID(), e.GetComment().GetUpdatedAt())
+	case *github.PullRequestEvent:
+		// Include the draft state so marking a draft as ready for review runs it.
+		return fmt.Sprintf("pull_request/%s/%s/%t", e.GetRepo().GetFullName(), e.GetPullRequest().GetHead().GetSHA(), e.GetPullRequest().GetDraft())
+	case *github.PullRequestReviewCommentEvent:
+		return fmt.Sprintf("pull_request_review_comment/%s/%d/%s", e.GetRepo().GetFullName(), e.GetComment().GetID(), e.GetComment().GetUpdatedAt())
+	case *github.RepositoryDispatchEvent:
+		// Each dispatch is explicitly requested, only the delivery is
+		// deduplicated.
+		return ""
+	case *mergeGroupEvent:
+		return fmt.Sprintf("merge_group/%s/%s", e.Repo.GetFullName(), e.MergeGroup.HeadSHA)
+	case *github.PushEvent:
+		return fmt.Sprintf("push/%s/%s/%s", e.GetRepo().GetFullName(), e.GetRef(), e.GetHeadCommit().GetID())
+	default:
+		return ""
+	}
+}
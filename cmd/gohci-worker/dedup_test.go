@@ -0,0 +1,48 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestRecentSet(t *testing.T) {
+	r := newRecentSet(time.Hour)
+	if !r.add("a") || r.add("a") || !r.add("b") {
+		t.Fatal("unexpected deduplication")
+	}
+	r = newRecentSet(0)
+	if !r.add("a") {
+		t.Fatal("unexpected deduplication")
+	}
+	time.Sleep(time.Millisecond)
+	if !r.add("a") {
+		t.Fatal("expected expiration")
+	}
+}
+
+func TestHookKey(t *testing.T) {
+	e := &github.PushEvent{
+		Ref:        github.String("refs/heads/main"),
+		Repo:       &github.PushEventRepository{FullName: github.String("periph/gohci")},
+		HeadCommit: &github.HeadCommit{ID: github.String("deadbeef")},
+	}
+	if k := hookKey(e); k != "push/periph/gohci/refs/heads/main/deadbeef" {
+		t.Fatalf("unexpected key %q", k)
+	}
+	m, err := parseHook("merge_group", []byte(`{"action":"checks_requested","merge_group":{"head_sha":"cafe","head_ref":"refs/heads/gh-readonly-queue/main/pr-1-cafe"},"repository":{"full_name":"periph/gohci"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := hookKey(m); k != "merge_group/periph/gohci/cafe" {
+		t.Fatalf("unexpected key %q", k)
+	}
+	if k := hookKey(&github.PingEvent{}); k != "" {
+		t.Fatalf("unexpected key %q", k)
+	}
+}
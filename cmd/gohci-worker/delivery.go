@@ -0,0 +1,241 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// webhookDelivery is a raw webhook payload as received from GitHub, kept to be
+// able to debug and replay it.
+type webhookDelivery struct {
+	ID       int64
+	GUID     string // X-GitHub-Delivery
+	Event    string // X-GitHub-Event
+	Query    string // Raw query, which contains altPath and superUsers.
+	Received time.Time
+	Payload  json.RawMessage `json:",omitempty"`
+}
+
+var bucketDeliveries = []byte("deliveries")
+
+// addDelivery stores d, keeping only the max most recent deliveries.
+func (h *jobHistory) addDelivery(d *webhookDelivery, max int) {
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucketDeliveries)
+		if err != nil {
+			return err
+		}
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		d.ID = int64(id)
+		v, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		if err = b.Put(key(d.ID), v); err != nil {
+			return err
+		}
+		// Trim the oldest ones.
+		var keys [][]byte
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			keys = append(keys, append([]byte(nil), k...))
+		}
+		for i := 0; i < len(keys)-max; i++ {
+			if err = b.Delete(keys[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logHistory.Error("failed to add delivery", "guid", d.GUID, "err", err)
+	}
+}
+
+// deliveries returns the stored deliveries without their payload, most recent
+// first.
+func (h *jobHistory) deliveries() []webhookDelivery {
+	var out []webhookDelivery
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var d webhookDelivery
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			d.Payload = nil
+			out = append(out, d)
+		}
+		return nil
+	})
+	if err != nil {
+		logHistory.Error("failed to list deliveries", "err", err)
+	}
+	return out
+}
+
+// delivery returns the delivery with the GUID specified.
+func (h *jobHistory) delivery(guid string) (webhookDelivery, bool) {
+	var d webhookDelivery
+	found := false
+	err := h.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeliveries)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			if d.GUID == guid {
+				found = true
+				return nil
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logHistory.Error("failed to read delivery", "guid", guid, "err", err)
+		return d, false
+	}
+	return d, found
+}
+
+//
+
+// isAuthorized returns true if the request has the webhook secret as a bearer
+// token.
+func (s *server) isAuthorized(r *http.Request) bool {
+	a := r.Header.Get("Authorization")
+	if !strings.HasPrefix(a, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(a[len("Bearer "):]), []byte(s.c.WebHookSecret)) == 1
+}
+
+// canView returns true if the request may read the dashboard, the feed and
+// the jobs' history and output.
+//
+// It's always true when dashboardusers is not set. Otherwise, it requires
+// HTTP basic authentication with one of the users, or the webhook secret as a
+// bearer token.
+func (s *server) canView(r *http.Request) bool {
+	if len(s.c.DashboardUsers) == 0 || s.isAuthorized(r) {
+		return true
+	}
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	got := []byte(user + ":" + password)
+	for _, u := range s.c.DashboardUsers {
+		if subtle.ConstantTimeCompare(got, []byte(u)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// requireView returns true if the request may view, otherwise it asks for
+// credentials.
+func (s *server) requireView(w http.ResponseWriter, r *http.Request) bool {
+	if s.canView(r) {
+		return true
+	}
+	logServer.Warn("unauthorized view", "remote", r.RemoteAddr, "path", r.URL.Path)
+	w.Header().Set("WWW-Authenticate", `Basic realm="gohci"`)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// serveDeliveries handles /api/v1/deliveries[/<guid>[/replay]].
+//
+// These are authenticated since payloads of private repositories are
+// sensitive and replaying runs code on the worker.
+func (s *server) serveDeliveries(w http.ResponseWriter, r *http.Request, p []string) {
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case len(p) == 1 && r.Method == "GET":
+		d := s.h.deliveries()
+		if d == nil {
+			d = []webhookDelivery{}
+		}
+		writeJSON(w, d)
+	case len(p) == 2 && r.Method == "GET":
+		d, ok := s.h.delivery(p[1])
+		if !ok {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, d)
+	case len(p) == 3 && p[2] == "replay" && r.Method == "POST":
+		d, ok := s.h.delivery(p[1])
+		if !ok {
+			http.Error(w, "Delivery not found", http.StatusNotFound)
+			return
+		}
+		if err := s.replay(&d); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, struct{}{})
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+// replay runs a stored delivery through the webhook handler again. A
+// coordinator forwards it to its workers instead.
+func (s *server) replay(d *webhookDelivery) error {
+	logServer.Info("replaying", "guid", d.GUID, "event", d.Event)
+	if s.reg != nil {
+		s.reg.dispatch(d)
+		return nil
+	}
+	return s.handleDelivery(d, false)
+}
+
+// handleDelivery runs a delivery that was received out of band through the
+// webhook handler.
+func (s *server) handleDelivery(d *webhookDelivery, dedupe bool) error {
+	values, err := url.ParseQuery(d.Query)
+	if err != nil {
+		return err
+	}
+	altPath, superUsers, err := validateArgs(values)
+	if err != nil {
+		return err
+	}
+	s.handleHook(d.Event, d.Payload, altPath, superUsers, dedupe)
+	return nil
+}
+
+// replayDelivery asks the worker running locally to replay a delivery.
+func replayDelivery(port int, secret, guid string) error {
+	if _, err := adminRequest(port, secret, "POST", "deliveries/"+url.PathEscape(guid)+"/replay"); err != nil {
+		return fmt.Errorf("replay failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/google/go-github/v31/github"
+)
+
+// createDeployment creates a GitHub deployment of the commit to the
+// environment of the repo's .gohci.yml and marks it in progress.
+//
+// It is best effort and doesn't fail the job. j.deploymentID is set on
+// success.
+//
+// This requires the OAuth scope "repo_deployment" or "repo".
+func (w *workerQueue) createDeployment(j *jobRequest, logURL string) {
+	// https://docs.github.com/rest/deployments/deployments#create-a-deployment
+	d, _, err := w.client.Repositories.CreateDeployment(w.ctx, j.org, j.repo, &github.DeploymentRequest{
+		Ref:         &j.commitHash,
+		AutoMerge:   github.Bool(false),
+		Environment: &j.deployment.Environment,
+		Description: github.String(w.name),
+		// The worker's own pending status must not block the deployment.
+		RequiredContexts:      &[]string{},
+		ProductionEnvironment: &j.deployment.Production,
+	})
+	if err != nil {
+		j.log.Error("failed to create deployment", "err", err)
+		return
+	}
+	j.log.Info("deployment created", "id", d.GetID(), "environment", j.deployment.Environment)
+	j.deploymentID = d.GetID()
+	w.deploymentStatus(j, "in_progress", logURL)
+}
+
+// deploymentStatus updates the state of the job's deployment, if any, e.g.
+// "in_progress", "success", "failure" or "error".
+func (w *workerQueue) deploymentStatus(j *jobRequest, state, logURL string) {
+	if j.deploymentID == 0 {
+		return
+	}
+	s := &github.DeploymentStatusRequest{State: &state}
+	if logURL != "" {
+		s.LogURL = &logURL
+	}
+	if j.deployment.EnvironmentURL != "" {
+		s.EnvironmentURL = &j.deployment.EnvironmentURL
+	}
+	// https://docs.github.com/rest/deployments/statuses#create-a-deployment-status
+	if _, _, err := w.client.Repositories.CreateDeploymentStatus(w.ctx, j.org, j.repo, j.deploymentID, s); err != nil {
+		j.log.Error("failed to update deployment status", "state", state, "err", err)
+	}
+}
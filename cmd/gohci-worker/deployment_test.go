@@ -0,0 +1,56 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+func TestDeployment(t *testing.T) {
+	var reqs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(b)))
+		switch r.URL.Path {
+		case "/repos/periph/gohci/deployments":
+			_, _ = io.WriteString(w, `{"id":42}`)
+		case "/repos/periph/gohci/deployments/42/statuses":
+			_, _ = io.WriteString(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{name: "w", ctx: context.Background(), client: client, gists: client}
+	j := &jobRequest{
+		jobSpec:    jobSpec{org: "periph", repo: "gohci", commitHash: "abc"},
+		log:        slog.Default(),
+		deployment: &gohci.Deployment{Environment: "lab"},
+	}
+	// Nothing happens without a deployment.
+	w.deploymentStatus(j, "success", "https://gist")
+	w.createDeployment(j, "https://gist")
+	w.deploymentStatus(j, "success", "https://gist")
+	want := []string{
+		`POST /repos/periph/gohci/deployments {"ref":"abc","auto_merge":false,"required_contexts":[],"environment":"lab","description":"w","production_environment":false}`,
+		`POST /repos/periph/gohci/deployments/42/statuses {"state":"in_progress","log_url":"https://gist"}`,
+		`POST /repos/periph/gohci/deployments/42/statuses {"state":"success","log_url":"https://gist"}`,
+	}
+	if j.deploymentID != 42 || strings.Join(reqs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("%d\n%s", j.deploymentID, strings.Join(reqs, "\n"))
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// exportStore is a blobStore that copies the files to a host with rsync or
+// sftp over SSH, e.g. the lab's NAS, for labs without cloud access.
+//
+// Each file is staged in a temporary directory that is deleted once copied,
+// so nothing accumulates locally.
+type exportStore struct {
+	method  string // "rsync" or "sftp".
+	target  string // "[user@]host:path" or a local path with rsync.
+	baseURL string // URL where target is served, optional.
+	dir     string // Parent of the temporary staging directories.
+}
+
+// upload implements blobStore.
+func (e *exportStore) upload(ctx context.Context, name, contentType, content string) error {
+	return e.sync(ctx, name, &content)
+}
+
+// remove implements blobStore.
+func (e *exportStore) remove(ctx context.Context, name string) error {
+	return e.sync(ctx, name, nil)
+}
+
+// signedURL implements blobStore. There's no signature, the host is expected
+// to be only reachable from the lab. It returns "" when there's no base URL.
+func (e *exportStore) signedURL(name string, now time.Time) (string, error) {
+	if e.baseURL == "" {
+		return "", nil
+	}
+	return strings.TrimSuffix(e.baseURL, "/") + "/" + uriEscape(name, true), nil
+}
+
+// String implements blobStore.
+func (e *exportStore) String() string {
+	return e.method + "://" + e.target
+}
+
+// sync copies the file name with content to the host, or deletes it when
+// content is nil.
+func (e *exportStore) sync(ctx context.Context, name string, content *string) error {
+	if err := os.MkdirAll(e.dir, 0o700); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(e.dir, "")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	p := filepath.Join(staging, filepath.FromSlash(name))
+	if err = os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	if content != nil {
+		if err = os.WriteFile(p, []byte(*content), 0o600); err != nil {
+			return err
+		}
+	}
+	cmd, stdin := e.command(staging, name, content == nil)
+	return e.run(ctx, cmd, stdin)
+}
+
+// command returns the command to copy the file name staged in staging to the
+// host or to delete it there, and the sftp batch to send to its stdin.
+func (e *exportStore) command(staging, name string, removed bool) ([]string, string) {
+	if e.method == "sftp" {
+		host, root, _ := strings.Cut(e.target, ":")
+		remote := path.Join(root, name)
+		if removed {
+			return []string{"sftp", "-b", "-", host}, "-rm " + sftpQuote(remote) + "\n"
+		}
+		// Create the parent directories, ignoring the errors when they exist.
+		batch := ""
+		for d, p := root, strings.Split(path.Dir(name), "/"); len(p) != 0 && p[0] != "."; p = p[1:] {
+			d = path.Join(d, p[0])
+			batch += "-mkdir " + sftpQuote(d) + "\n"
+		}
+		batch += "put " + sftpQuote(filepath.Join(staging, filepath.FromSlash(name))) + " " + sftpQuote(remote) + "\n"
+		return []string{"sftp", "-b", "-", host}, batch
+	}
+	dst := strings.TrimSuffix(e.target, "/") + "/"
+	if removed {
+		// Sync the now empty parent directory, deleting only this file; the
+		// excluded files are left alone.
+		if d := path.Dir(name); d != "." {
+			dst += d + "/"
+		}
+		src := filepath.Dir(filepath.Join(staging, filepath.FromSlash(name))) + "/"
+		return []string{"rsync", "-r", "--delete", "--include=/" + rsyncEscape(path.Base(name)), "--exclude=*", src, dst}, ""
+	}
+	// "/./" tells --relative to recreate the parent directories on the host.
+	return []string{"rsync", "-a", "--relative", staging + "/./" + name, dst}, ""
+}
+
+func (e *exportStore) run(ctx context.Context, cmd []string, stdin string) error {
+	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	if stdin != "" {
+		c.Stdin = strings.NewReader(stdin)
+	}
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// rsyncEscape escapes the wildcards of a rsync filter pattern.
+func rsyncEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(s)
+}
+
+// sftpQuote quotes a path for a sftp batch file.
+func sftpQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportStore(t *testing.T) {
+	e := &exportStore{method: "rsync", target: "nas:/srv/ci/", dir: "/w/export"}
+	cmd, stdin := e.command("/w/export/1", "o/r/abc-1/cmd1 go test ._...", false)
+	want := []string{"rsync", "-a", "--relative", "/w/export/1/./o/r/abc-1/cmd1 go test ._...", "nas:/srv/ci/"}
+	if !reflect.DeepEqual(cmd, want) || stdin != "" {
+		t.Fatal(cmd, stdin)
+	}
+	cmd, _ = e.command("/w/export/1", "o/r/abc-1/cmd1 go test ._...", true)
+	if want = []string{"rsync", "-r", "--delete", "--include=/cmd1 go test ._...", "--exclude=*", "/w/export/1/o/r/abc-1/", "nas:/srv/ci/o/r/abc-1/"}; !reflect.DeepEqual(cmd, want) {
+		t.Fatal(cmd)
+	}
+	cmd, _ = e.command("/w/export/1", "self[test]*", true)
+	if want = []string{"rsync", "-r", "--delete", "--include=/self\\[test]\\*", "--exclude=*", "/w/export/1/", "nas:/srv/ci/"}; !reflect.DeepEqual(cmd, want) {
+		t.Fatal(cmd)
+	}
+	if u, err := e.signedURL("o/r/index.html", time.Now()); u != "" || err != nil {
+		t.Fatal(u, err)
+	}
+	e.baseURL = "http://nas/ci/"
+	if u, _ := e.signedURL("o/r/a b", time.Now()); u != "http://nas/ci/o/r/a%20b" {
+		t.Fatal(u)
+	}
+
+	e = &exportStore{method: "sftp", target: "ci@nas:/srv/ci", dir: "/w/export"}
+	cmd, stdin = e.command("/w/export/1", "o/r/a\"b", false)
+	if want = []string{"sftp", "-b", "-", "ci@nas"}; !reflect.DeepEqual(cmd, want) {
+		t.Fatal(cmd)
+	}
+	if w := "-mkdir \"/srv/ci/o\"\n-mkdir \"/srv/ci/o/r\"\nput \"/w/export/1/o/r/a\\\"b\" \"/srv/ci/o/r/a\\\"b\"\n"; stdin != w {
+		t.Fatalf("%q", stdin)
+	}
+	if _, stdin = e.command("/w/export/1", "o/r/x", true); stdin != "-rm \"/srv/ci/o/r/x\"\n" {
+		t.Fatalf("%q", stdin)
+	}
+}
+
+func TestExportStoreStaging(t *testing.T) {
+	// A local target with rsync.
+	dst := t.TempDir()
+	e := &exportStore{method: "rsync", target: dst, dir: filepath.Join(t.TempDir(), "export")}
+	ctx := context.Background()
+	_, errRsync := exec.LookPath("rsync")
+	for _, n := range []string{"o/r/1/a b", "o/r/1/c"} {
+		if err := e.upload(ctx, n, "text/plain", n); (err == nil) != (errRsync == nil) {
+			t.Fatal(err)
+		}
+	}
+	// Nothing is left behind, even on failure.
+	if f, err := os.ReadDir(e.dir); err != nil || len(f) != 0 {
+		t.Fatal(f, err)
+	}
+	if errRsync != nil {
+		t.Skip("rsync is not installed")
+	}
+	if err := e.remove(ctx, "o/r/1/a b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "o", "r", "1", "a b")); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "o", "r", "1", "c")); err != nil || string(b) != "o/r/1/c" {
+		t.Fatal(string(b), err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// atomFeed is the subset of RFC 4287 used to publish the recent jobs.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+	Summary string   `xml:"summary"`
+}
+
+// serveFeed serves the recent completed jobs as an Atom feed.
+func (s *server) serveFeed(w http.ResponseWriter) {
+	f := atomFeed{
+		Title:   "gohci - " + s.c.Name,
+		ID:      "urn:gohci:" + s.c.Name,
+		Updated: s.start.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: s.c.Name},
+	}
+	for _, j := range s.w.jobs(jobFilter{Limit: 50}) {
+		if j.State != "success" && j.State != "failure" {
+			continue
+		}
+		done := j.Started.Add(j.Duration).UTC().Format(time.RFC3339)
+		if done > f.Updated {
+			f.Updated = done
+		}
+		e := atomEntry{
+			Title:   fmt.Sprintf("%s/%s at %s: %s", j.Org, j.Repo, shortHash(j.Commit), j.State),
+			ID:      fmt.Sprintf("urn:gohci:%s:job:%d", s.c.Name, j.ID),
+			Updated: done,
+			Link:    atomLink{Href: j.GistURL},
+		}
+		if j.PullID != 0 {
+			e.Title = fmt.Sprintf("%s/%s#%d at %s: %s", j.Org, j.Repo, j.PullID, shortHash(j.Commit), j.State)
+		}
+		var lines []string
+		for _, c := range j.Checks {
+			st := "ok"
+			if !c.Success {
+				st = "FAILED"
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s in %s", c.Name, st, roundDuration(c.Duration)))
+		}
+		e.Summary = strings.Join(lines, "\n")
+		f.Entries = append(f.Entries, e)
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_, _ = io.WriteString(w, xml.Header)
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+	if err := e.Encode(f); err != nil {
+		logServer.Error("failed to encode feed", "err", err)
+	}
+}
+
+// shortHash returns the abbreviated commit hash.
+func shortHash(s string) string {
+	if len(s) > 12 {
+		return s[:12]
+	}
+	return s
+}
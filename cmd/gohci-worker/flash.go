@@ -0,0 +1,93 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"periph.io/x/gohci"
+)
+
+// checkCmd returns the command to run for the check, generating it for the
+// declarative check kinds.
+func checkCmd(c *gohci.Check) ([]string, error) {
+	kinds := 0
+	for _, set := range []bool{len(c.Cmd) != 0, c.Flash != nil, c.TinyGo != nil} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return nil, errors.New("cmd, flash and tinygo are mutually exclusive")
+	}
+	if c.Flash != nil {
+		return flashCmd(c.Flash)
+	}
+	if c.TinyGo != nil {
+		return tinyGoCmd(c.TinyGo)
+	}
+	return c.Cmd, nil
+}
+
+// checkAttempts returns the number of times the check is tried until it
+// succeeds.
+func checkAttempts(c *gohci.Check) int {
+	retries := 0
+	if c.Flash != nil {
+		retries = c.Flash.Retries
+	} else if c.TinyGo != nil && c.TinyGo.Command == "flash" {
+		retries = c.TinyGo.Retries
+	} else {
+		return 1
+	}
+	if retries < 0 {
+		return 1
+	}
+	if retries == 0 {
+		// Flashing is notoriously flaky.
+		return 3
+	}
+	return retries + 1
+}
+
+// flashCmd returns the command to flash the binary with the tool.
+func flashCmd(f *gohci.Flash) ([]string, error) {
+	if f.Binary == "" {
+		return nil, errors.New("flash: binary is required")
+	}
+	switch f.Tool {
+	case "esptool":
+		if f.Port == "" {
+			return nil, errors.New("flash: port is required with esptool")
+		}
+		cmd := []string{"esptool.py", "--port", f.Port}
+		if f.Target != "" {
+			cmd = append(cmd, "--chip", f.Target)
+		}
+		addr := f.Address
+		if addr == "" {
+			addr = "0x0"
+		}
+		return append(cmd, "write_flash", addr, f.Binary), nil
+	case "openocd":
+		if f.Target == "" {
+			return nil, errors.New("flash: target is required with openocd")
+		}
+		p := "program " + f.Binary + " verify reset exit"
+		if f.Address != "" {
+			p += " " + f.Address
+		}
+		return []string{"openocd", "-f", f.Target, "-c", p}, nil
+	case "picotool":
+		cmd := []string{"picotool", "load", "-x", "-f"}
+		if f.Address != "" {
+			cmd = append(cmd, "-o", f.Address)
+		}
+		return append(cmd, f.Binary), nil
+	default:
+		return nil, fmt.Errorf("flash: unknown tool %q; use esptool, openocd or picotool", f.Tool)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestCheckCmd(t *testing.T) {
+	data := []struct {
+		c    gohci.Check
+		want []string
+	}{
+		{gohci.Check{Cmd: []string{"go", "test"}}, []string{"go", "test"}},
+		{
+			gohci.Check{Flash: &gohci.Flash{Tool: "esptool", Target: "esp32", Port: "/dev/ttyUSB0", Binary: "fw.bin"}},
+			[]string{"esptool.py", "--port", "/dev/ttyUSB0", "--chip", "esp32", "write_flash", "0x0", "fw.bin"},
+		},
+		{
+			gohci.Check{Flash: &gohci.Flash{Tool: "openocd", Target: "board/st_nucleo_f4.cfg", Binary: "fw.elf"}},
+			[]string{"openocd", "-f", "board/st_nucleo_f4.cfg", "-c", "program fw.elf verify reset exit"},
+		},
+		{
+			gohci.Check{Flash: &gohci.Flash{Tool: "openocd", Target: "target/stm32f4x.cfg", Binary: "fw.bin", Address: "0x08000000"}},
+			[]string{"openocd", "-f", "target/stm32f4x.cfg", "-c", "program fw.bin verify reset exit 0x08000000"},
+		},
+		{
+			gohci.Check{Flash: &gohci.Flash{Tool: "picotool", Binary: "fw.uf2"}},
+			[]string{"picotool", "load", "-x", "-f", "fw.uf2"},
+		},
+	}
+	for i, l := range data {
+		got, err := checkCmd(&l.c)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, l.want) {
+			t.Fatalf("#%d: %q != %q", i, got, l.want)
+		}
+	}
+}
+
+func TestCheckCmdInvalid(t *testing.T) {
+	data := []gohci.Check{
+		{Cmd: []string{"true"}, Flash: &gohci.Flash{Tool: "picotool", Binary: "fw.uf2"}},
+		{Flash: &gohci.Flash{Tool: "picotool"}},
+		{Flash: &gohci.Flash{Tool: "esptool", Binary: "fw.bin"}},
+		{Flash: &gohci.Flash{Tool: "openocd", Binary: "fw.bin"}},
+		{Flash: &gohci.Flash{Tool: "dfu-util", Binary: "fw.bin"}},
+	}
+	for i, c := range data {
+		if _, err := checkCmd(&c); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestCheckAttempts(t *testing.T) {
+	data := []struct {
+		c    gohci.Check
+		want int
+	}{
+		{gohci.Check{Cmd: []string{"true"}}, 1},
+		{gohci.Check{Flash: &gohci.Flash{}}, 3},
+		{gohci.Check{Flash: &gohci.Flash{Retries: -1}}, 1},
+		{gohci.Check{Flash: &gohci.Flash{Retries: 4}}, 5},
+	}
+	for i, l := range data {
+		if got := checkAttempts(&l.c); got != l.want {
+			t.Fatalf("#%d: %d != %d", i, got, l.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcsClient is a blobStore in a Google Cloud Storage bucket, with a service
+// account.
+//
+// It only uses the JSON API and V4 signed URLs so it doesn't need the Google
+// Cloud SDK.
+type gcsClient struct {
+	bucket   string
+	email    string
+	key      *rsa.PrivateKey
+	tokenURI string
+	api      string // https://storage.googleapis.com, overridden in tests.
+	expiry   time.Duration
+	client   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newGCSClient loads the service account JSON key file.
+func newGCSClient(bucket, credentials string, expiry time.Duration) (*gcsClient, error) {
+	b, err := os.ReadFile(credentials)
+	if err != nil {
+		return nil, err
+	}
+	var sa struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+		TokenURI    string `json:"token_uri"`
+	}
+	if err = json.Unmarshal(b, &sa); err != nil {
+		return nil, fmt.Errorf("%s: %w", credentials, err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" {
+		return nil, fmt.Errorf("%s: not a service account key", credentials)
+	}
+	p, _ := pem.Decode([]byte(sa.PrivateKey))
+	if p == nil {
+		return nil, fmt.Errorf("%s: invalid private_key", credentials)
+	}
+	k, err := x509.ParsePKCS8PrivateKey(p.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", credentials, err)
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: private_key is not RSA", credentials)
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &gcsClient{
+		bucket:   bucket,
+		email:    sa.ClientEmail,
+		key:      key,
+		tokenURI: sa.TokenURI,
+		api:      "https://storage.googleapis.com",
+		expiry:   expiry,
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// accessToken returns a cached OAuth2 access token, exchanging a signed JWT
+// for a new one when needed.
+func (g *gcsClient) accessToken(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if g.token != "" && now.Before(g.expires) {
+		return g.token, nil
+	}
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   g.email,
+		"scope": "https://www.googleapis.com/auth/devstorage.read_write",
+		"aud":   g.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	jwt := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(claims)
+	sig, err := g.sign(jwt)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {jwt + "." + enc.EncodeToString(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", g.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = g.do(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to get a GCS access token: %w", err)
+	}
+	g.token = resp.AccessToken
+	// Renew a bit early.
+	g.expires = now.Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return g.token, nil
+}
+
+// upload implements blobStore.
+func (g *gcsClient) upload(ctx context.Context, name, contentType, content string) error {
+	u := g.api + "/upload/storage/v1/b/" + url.PathEscape(g.bucket) + "/o?uploadType=media&name=" + url.QueryEscape(name)
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return g.authDo(ctx, req)
+}
+
+// remove implements blobStore.
+func (g *gcsClient) remove(ctx context.Context, name string) error {
+	u := g.api + "/storage/v1/b/" + url.PathEscape(g.bucket) + "/o/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", u, nil)
+	if err != nil {
+		return err
+	}
+	return g.authDo(ctx, req)
+}
+
+func (g *gcsClient) authDo(ctx context.Context, req *http.Request) error {
+	t, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t)
+	return g.do(req, nil)
+}
+
+// do sends the request and decodes the JSON response into out, if not nil.
+func (g *gcsClient) do(req *http.Request, out interface{}) error {
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if out != nil {
+		return json.Unmarshal(b, out)
+	}
+	return nil
+}
+
+// String implements blobStore.
+func (g *gcsClient) String() string {
+	return "gs://" + g.bucket
+}
+
+func (g *gcsClient) sign(s string) ([]byte, error) {
+	h := sha256.Sum256([]byte(s))
+	return rsa.SignPKCS1v15(rand.Reader, g.key, crypto.SHA256, h[:])
+}
+
+// signedURL implements blobStore with a V4 signed URL.
+//
+// https://cloud.google.com/storage/docs/access-control/signing-urls-manually
+func (g *gcsClient) signedURL(name string, now time.Time) (string, error) {
+	now = now.UTC()
+	date := now.Format("20060102")
+	ts := now.Format("20060102T150405Z")
+	scope := date + "/auto/storage/goog4_request"
+	path := "/" + uriEscape(g.bucket, false) + "/" + uriEscape(name, true)
+	// The parameters are already sorted.
+	query := "X-Goog-Algorithm=GOOG4-RSA-SHA256" +
+		"&X-Goog-Credential=" + uriEscape(g.email+"/"+scope, false) +
+		"&X-Goog-Date=" + ts +
+		"&X-Goog-Expires=" + strconv.Itoa(int(g.expiry/time.Second)) +
+		"&X-Goog-SignedHeaders=host"
+	canonical := "GET\n" + path + "\n" + query + "\nhost:storage.googleapis.com\n\nhost\nUNSIGNED-PAYLOAD"
+	h := sha256.Sum256([]byte(canonical))
+	sig, err := g.sign("GOOG4-RSA-SHA256\n" + ts + "\n" + scope + "\n" + hex.EncodeToString(h[:]))
+	if err != nil {
+		return "", err
+	}
+	return "https://storage.googleapis.com" + path + "?" + query + "&X-Goog-Signature=" + hex.EncodeToString(sig), nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestGCS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := map[string]string{}
+	tokens := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/token":
+			if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || strings.Count(r.FormValue("assertion"), ".") != 2 {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			tokens++
+			_, _ = io.WriteString(w, `{"access_token":"tok","expires_in":3600}`)
+		case r.Header.Get("Authorization") != "Bearer tok":
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		case r.Method == "POST" && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Query().Get("name")] = string(b)
+			_, _ = io.WriteString(w, "{}")
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"):
+			delete(objects, strings.TrimPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "ci@project.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    ts.URL + "/token",
+	})
+	p := filepath.Join(t.TempDir(), "sa.json")
+	if err = os.WriteFile(p, sa, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGCSClient("bucket", p, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.api = ts.URL
+
+	w := &workerQueue{ctx: context.Background(), blobs: g}
+	j := &jobRequest{jobSpec: jobSpec{org: "periph", repo: "gohci", commitHash: "abc"}, id: 42, log: slog.Default()}
+	gist, err := w.blobCreate(j, &github.Gist{
+		Description: github.String("w for periph/gohci"),
+		Files:       map[github.GistFilename]github.GistFile{"setup-0-metadata": {Content: github.String("meta")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := gist.GetID()
+	if prefix != "periph/gohci/abc-42/" || !strings.HasPrefix(gist.GetHTMLURL(), "https://storage.googleapis.com/bucket/periph/gohci/abc-42/") {
+		t.Fatal(prefix, gist.GetHTMLURL())
+	}
+	o := newGistOutput(gist)
+	o.add("cmd1 go test ./...", "ok")
+	o.setSuffix(" (1/1)")
+	if !w.gist(j, o) {
+		t.Fatal("expected success")
+	}
+	w.uploadPartial(j, o, "running")
+	if objects[prefix+gistRunningFile] != "running" {
+		t.Fatal(objects)
+	}
+	w.uploadPartial(j, o, "")
+	var names []string
+	for n := range objects {
+		names = append(names, strings.TrimPrefix(n, prefix))
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "cmd1 go test ._...,index.html,setup-0-metadata" {
+		t.Fatal(got)
+	}
+	if idx := objects[prefix+"index.html"]; !strings.Contains(idx, "<h1>w for periph/gohci (1/1)</h1>") || !strings.Contains(idx, ">cmd1 go test ./...</a>") {
+		t.Fatal(idx)
+	}
+	if tokens != 1 {
+		t.Fatal(tokens)
+	}
+}
+
+func TestGCSSignedURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &gcsClient{bucket: "bucket", email: "ci@p.iam.gserviceaccount.com", key: key, expiry: time.Hour}
+	u, err := g.signedURL("a/b c", time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, sig, ok := strings.Cut(u, "&X-Goog-Signature=")
+	want := "https://storage.googleapis.com/bucket/a/b%20c?X-Goog-Algorithm=GOOG4-RSA-SHA256&X-Goog-Credential=ci%40p.iam.gserviceaccount.com%2F20260102%2Fauto%2Fstorage%2Fgoog4_request&X-Goog-Date=20260102T030405Z&X-Goog-Expires=3600&X-Goog-SignedHeaders=host"
+	if !ok || base != want {
+		t.Fatal(u)
+	}
+	path, query, _ := strings.Cut(strings.TrimPrefix(base, "https://storage.googleapis.com"), "?")
+	h := sha256.Sum256([]byte("GET\n" + path + "\n" + query + "\nhost:storage.googleapis.com\n\nhost\nUNSIGNED-PAYLOAD"))
+	s := sha256.Sum256([]byte("GOOG4-RSA-SHA256\n20260102T030405Z\n20260102/auto/storage/goog4_request\n" + hex.EncodeToString(h[:])))
+	b, err := hex.DecodeString(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, s[:], b); err != nil {
+		t.Fatal(err)
+	}
+}
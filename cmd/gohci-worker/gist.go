@@ -0,0 +1,247 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// Limits of a single gist. The API starts failing or truncating beyond these.
+const (
+	gistMaxFiles = 300
+	gistMaxSize  = 10 * 1024 * 1024
+)
+
+// gistFileContent is a file pending upload.
+type gistFileContent struct {
+	name, content string
+}
+
+// gistOutput tracks the gists holding the output of a job.
+//
+// A single gist is limited in number of files and total size, and Edit fails
+// beyond that, losing the output. Once the current gist is full, the
+// following files are spilled into continuation gists which are cross-linked
+// from the primary gist's description.
+type gistOutput struct {
+	primary *github.Gist // Linked from the commit status.
+	cur     *github.Gist // The gist being filled, initially primary.
+	desc    string       // Base description.
+	suffix  string       // Progress suffix appended to desc.
+	files   int          // Number of files in cur.
+	size    int          // Total size of files in cur.
+	conts   []string     // URLs of the continuation gists.
+	pending []gistFileContent
+	dirty   bool // The primary's description needs to be updated.
+}
+
+func newGistOutput(g *github.Gist) *gistOutput {
+	o := &gistOutput{primary: g, cur: g, desc: g.GetDescription()}
+	for _, f := range g.Files {
+		o.files++
+		o.size += len(f.GetContent())
+	}
+	return o
+}
+
+// url returns the URL of the primary gist.
+func (o *gistOutput) url() string {
+	return o.primary.GetHTMLURL()
+}
+
+// add enqueues a file to be uploaded on the next flush.
+func (o *gistOutput) add(name, content string) {
+	o.pending = append(o.pending, gistFileContent{name, content})
+}
+
+// setSuffix sets the progress suffix of the description.
+func (o *gistOutput) setSuffix(suffix string) {
+	if o.suffix != suffix {
+		o.suffix = suffix
+		o.dirty = true
+	}
+}
+
+// description returns the primary gist's description.
+func (o *gistOutput) description() string {
+	d := o.desc + o.suffix
+	if len(o.conts) != 0 {
+		d += " (continued in " + strings.Join(o.conts, ", ") + ")"
+	}
+	return d
+}
+
+// fits returns true if a file of size n can be added to the current gist.
+func (o *gistOutput) fits(n int) bool {
+	// Always accept at least one file per gist.
+	return o.files == 0 || (o.files+1 <= gistMaxFiles && o.size+n <= gistMaxSize)
+}
+
+// gist uploads the pending files and the updated description.
+//
+// Files are automatically carried over by the API so only the new ones are
+// sent.
+func (w *workerQueue) gist(j *jobRequest, o *gistOutput) bool {
+	if j.local {
+		for _, f := range o.pending {
+			writeResult(w.wd, j, f.name, f.content)
+		}
+		o.pending = nil
+		return true
+	}
+	if w.blobs != nil {
+		return w.blobFlush(j, o)
+	}
+	ok := true
+	var batch []gistFileContent
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.editGist(j, o, batch); err != nil {
+			var e *github.ErrorResponse
+			if errors.As(err, &e) && e.Response != nil && e.Response.StatusCode == http.StatusUnprocessableEntity {
+				// The gist is full even if the limits were not hit.
+				j.log.Warn("gist is full, spilling over", "err", err)
+				if err = w.continueGist(j, o, batch); err == nil {
+					batch = nil
+					return
+				}
+			}
+			j.log.Error("failed to update gist", "err", err)
+			ok = false
+		}
+		batch = nil
+	}
+	for _, f := range o.pending {
+		if !o.fits(len(f.content)) {
+			flush()
+			if err := w.continueGist(j, o, []gistFileContent{f}); err != nil {
+				j.log.Error("failed to create continuation gist", "err", err)
+				ok = false
+			}
+			continue
+		}
+		batch = append(batch, f)
+		o.files++
+		o.size += len(f.content)
+	}
+	flush()
+	o.pending = nil
+	if o.dirty {
+		if _, _, err := w.gists.Gists.Edit(w.ctx, o.primary.GetID(), &github.Gist{Description: github.String(o.description())}); err != nil {
+			j.log.Error("failed to update gist", "err", err)
+			ok = false
+		} else {
+			o.dirty = false
+		}
+	}
+	return ok
+}
+
+// setupCollapser merges the output of the successful setup steps in a single
+// gist file, to reduce the noise. A failed step keeps its own file.
+type setupCollapser struct {
+	content string
+	n       int // Number of files flushed so far.
+}
+
+// add merges the file if it is a successful setup step. Returns false if it
+// wasn't merged.
+func (s *setupCollapser) add(name, content string, success bool) bool {
+	if !success || !strings.HasPrefix(name, "setup-") {
+		return false
+	}
+	s.content += "--- " + name + "\n" + content
+	if !strings.HasSuffix(content, "\n") {
+		s.content += "\n"
+	}
+	return true
+}
+
+// flush adds the merged steps to the gist, if any.
+func (s *setupCollapser) flush(o *gistOutput) {
+	if s.content == "" {
+		return
+	}
+	name := "setup"
+	if s.n != 0 {
+		name = fmt.Sprintf("setup (%d)", s.n+1)
+	}
+	o.add(name, s.content)
+	s.content = ""
+	s.n++
+}
+
+// writeResult writes a file of a job reporting locally to
+// <wd>/results/<job id>/, or to stdout when testing a local directory.
+func writeResult(wd string, j *jobRequest, name, content string) {
+	if j.localDir != "" {
+		fmt.Printf("--- %s\n%s\n", name, strings.TrimRight(content, "\n"))
+		return
+	}
+	d := filepath.Join(wd, "results", strconv.FormatInt(j.id, 10))
+	if err := os.MkdirAll(d, 0o700); err != nil {
+		j.log.Error("failed to write result", "err", err)
+		return
+	}
+	p := filepath.Join(d, strings.Replace(name, string(os.PathSeparator), "_", -1))
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		j.log.Error("failed to write result", "err", err)
+		return
+	}
+	j.log.Info("result written", "path", p)
+}
+
+// editGist adds files to the current gist. When the current gist is the
+// primary one, the description is updated in the same call.
+func (w *workerQueue) editGist(j *jobRequest, o *gistOutput, files []gistFileContent) error {
+	g := &github.Gist{Files: map[github.GistFilename]github.GistFile{}}
+	for i := range files {
+		g.Files[github.GistFilename(files[i].name)] = github.GistFile{Content: &files[i].content}
+	}
+	if o.cur == o.primary {
+		g.Description = github.String(o.description())
+	}
+	_, _, err := w.gists.Gists.Edit(w.ctx, o.cur.GetID(), g)
+	if err == nil && o.cur == o.primary {
+		o.dirty = false
+	}
+	return err
+}
+
+// continueGist creates a new continuation gist holding files and makes it the
+// current one.
+func (w *workerQueue) continueGist(j *jobRequest, o *gistOutput, files []gistFileContent) error {
+	g := &github.Gist{
+		Description: github.String(fmt.Sprintf("%s (part %d), see %s", o.desc, len(o.conts)+2, o.url())),
+		Public:      github.Bool(false),
+		Files:       map[github.GistFilename]github.GistFile{},
+	}
+	size := 0
+	for i := range files {
+		g.Files[github.GistFilename(files[i].name)] = github.GistFile{Content: &files[i].content}
+		size += len(files[i].content)
+	}
+	g, _, err := w.gists.Gists.Create(w.ctx, g)
+	if err != nil {
+		return err
+	}
+	j.log.Info("continuation gist created", "url", g.GetHTMLURL())
+	o.cur = g
+	o.files = len(files)
+	o.size = size
+	o.conts = append(o.conts, g.GetHTMLURL())
+	o.dirty = true
+	return nil
+}
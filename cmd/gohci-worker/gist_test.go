@@ -0,0 +1,59 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestGistOutput(t *testing.T) {
+	o := newGistOutput(&github.Gist{
+		Description: github.String("w for x"),
+		HTMLURL:     github.String("https://gist/1"),
+		Files: map[github.GistFilename]github.GistFile{
+			"setup-0-metadata": {Content: github.String("abc")},
+		},
+	})
+	if o.files != 1 || o.size != 3 {
+		t.Fatalf("unexpected accounting %d %d", o.files, o.size)
+	}
+	if !o.fits(10) || o.fits(gistMaxSize) {
+		t.Fatal("unexpected fits")
+	}
+	o.setSuffix(" (1/2)")
+	o.conts = []string{"https://gist/2"}
+	if d := o.description(); d != "w for x (1/2) (continued in https://gist/2)" {
+		t.Fatalf("unexpected description %q", d)
+	}
+	o.files = 0
+	if !o.fits(gistMaxSize + 1) {
+		t.Fatal("an empty gist must accept one file")
+	}
+}
+
+func TestSetupCollapser(t *testing.T) {
+	o := newGistOutput(&github.Gist{})
+	s := &setupCollapser{}
+	if !s.add("setup-0-precleanup in 1ms", "", true) || !s.add("setup-1-clone in 2s", "cloned", true) {
+		t.Fatal("expected merged")
+	}
+	if s.add("setup-2-reset FAILED in 1s", "boom\n", false) || s.add("cmd1 in 1s", "ok\n", true) {
+		t.Fatal("expected not merged")
+	}
+	s.flush(o)
+	s.flush(o)
+	s.add("setup-3-post-cleanup in 1ms", "done\n", true)
+	s.flush(o)
+	want := []gistFileContent{
+		{"setup", "--- setup-0-precleanup in 1ms\n\n--- setup-1-clone in 2s\ncloned\n"},
+		{"setup (2)", "--- setup-3-post-cleanup in 1ms\ndone\n"},
+	}
+	if !reflect.DeepEqual(o.pending, want) {
+		t.Fatalf("%q", o.pending)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// heartbeatInterval is how often the commit status is updated while a check
+// runs, so users know the worker is alive.
+const heartbeatInterval = time.Minute
+
+// setRunning records the check being run, or none when name is "".
+func (j *jobRequest) setRunning(name string, since time.Time) {
+	j.muRunning.Lock()
+	defer j.muRunning.Unlock()
+	j.running = name
+	j.runningSince = since
+}
+
+// heartbeat returns the status description for the check running for at least
+// heartbeatInterval, e.g. "Running cmd3 for 12m…", or "".
+func (j *jobRequest) heartbeat(now time.Time) string {
+	j.muRunning.Lock()
+	defer j.muRunning.Unlock()
+	d := now.Sub(j.runningSince).Truncate(time.Minute)
+	if j.running == "" || d < heartbeatInterval {
+		return ""
+	}
+	return fmt.Sprintf("Running %s for %s…", j.running, strings.TrimSuffix(d.String(), "0s"))
+}
@@ -0,0 +1,36 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	j := &jobRequest{}
+	now := time.Now()
+	if got := j.heartbeat(now); got != "" {
+		t.Fatal(got)
+	}
+	j.setRunning("cmd3", now)
+	data := []struct {
+		d    time.Duration
+		want string
+	}{
+		{59 * time.Second, ""},
+		{12*time.Minute + 30*time.Second, "Running cmd3 for 12m…"},
+		{65 * time.Minute, "Running cmd3 for 1h5m…"},
+	}
+	for _, l := range data {
+		if got := j.heartbeat(now.Add(l.d)); got != l.want {
+			t.Fatalf("%s: %q", l.d, got)
+		}
+	}
+	j.setRunning("", time.Time{})
+	if got := j.heartbeat(now.Add(time.Hour)); got != "" {
+		t.Fatal(got)
+	}
+}
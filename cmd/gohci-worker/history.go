@@ -0,0 +1,263 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// checkResult is the result of a single gist file, either a check or a setup
+// step.
+type checkResult struct {
+	Name     string
+	Success  bool
+	Duration time.Duration
+}
+
+// jobRecord is the persisted summary of a job.
+type jobRecord struct {
+	ID       int64
+	Org      string
+	Repo     string
+	Commit   string
+	PullID   int
+	Started  time.Time
+	Duration time.Duration
+	// State is one of "pending", "running", "success", "failure", "skipped",
+	// "canceled" or "error" when the job couldn't be started.
+	State   string
+	GistURL string
+	Checks  []checkResult
+
+	// Used to retry the job.
+	Ref        string `json:",omitempty"`
+	AltPath    string `json:",omitempty"`
+	UseSSH     bool   `json:",omitempty"`
+	Restricted bool   `json:",omitempty"`
+	Local      bool   `json:",omitempty"`
+	Event      string `json:",omitempty"`
+
+	// Note explains the state, e.g. why the job ended in error.
+	Note string `json:",omitempty"`
+}
+
+// jobFilter selects jobs when listing the history.
+type jobFilter struct {
+	Org   string // Only jobs for this organization, if set.
+	Repo  string // Only jobs for this repository, if set.
+	State string // Only jobs in this state, if set.
+	Limit int    // Maximum number of jobs to return; 0 means no limit.
+}
+
+func (f *jobFilter) match(r *jobRecord) bool {
+	return (f.Org == "" || f.Org == r.Org) && (f.Repo == "" || f.Repo == r.Repo) && (f.State == "" || f.State == r.State)
+}
+
+var bucketJobs = []byte("jobs")
+
+// jobHistory stores every job's metadata and per-check results in an
+// embedded database, so it survives restarts.
+type jobHistory struct {
+	db      *bolt.DB
+	maxJobs int
+	maxAge  time.Duration
+}
+
+// openHistory opens or creates the history database at fileName.
+//
+// Jobs beyond maxJobs or older than maxAge are deleted as new jobs are added.
+// A zero value disables the corresponding limit.
+//
+// The jobs left pending or running by the previous process can't complete
+// anymore, so they are marked as interrupted.
+func openHistory(fileName string, maxJobs int, maxAge time.Duration) (*jobHistory, error) {
+	db, err := bolt.Open(fileName, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, fmt.Errorf("%s is locked, is another gohci-worker running?", fileName)
+		}
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err2 := tx.CreateBucketIfNotExists(bucketJobs)
+		if err2 != nil {
+			return err2
+		}
+		var stale []*jobRecord
+		err2 = b.ForEach(func(k, v []byte) error {
+			var r jobRecord
+			if json.Unmarshal(v, &r) == nil && (r.State == "pending" || r.State == "running") {
+				stale = append(stale, &r)
+			}
+			return nil
+		})
+		if err2 != nil {
+			return err2
+		}
+		for _, r := range stale {
+			r.State = "error"
+			r.Note = "interrupted"
+			if err2 = put(b, r); err2 != nil {
+				return err2
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &jobHistory{db: db, maxJobs: maxJobs, maxAge: maxAge}, nil
+}
+
+// close closes the database.
+func (h *jobHistory) close() error {
+	return h.db.Close()
+}
+
+// add assigns an ID to r and stores it, then enforces the retention policy.
+func (h *jobHistory) add(r *jobRecord) int64 {
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketJobs)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		r.ID = int64(id)
+		if err = put(b, r); err != nil {
+			return err
+		}
+		return h.prune(b)
+	})
+	if err != nil {
+		logHistory.Error("failed to add job", "err", err)
+	}
+	return r.ID
+}
+
+// update calls f on the job with the corresponding ID, then saves it.
+func (h *jobHistory) update(id int64, f func(r *jobRecord)) {
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketJobs)
+		v := b.Get(key(id))
+		if v == nil {
+			return nil
+		}
+		r := &jobRecord{}
+		if err := json.Unmarshal(v, r); err != nil {
+			return err
+		}
+		f(r)
+		return put(b, r)
+	})
+	if err != nil {
+		logHistory.Error("failed to update job", "job_id", id, "err", err)
+	}
+}
+
+// get returns the job with the corresponding ID.
+func (h *jobHistory) get(id int64) (jobRecord, bool) {
+	var r jobRecord
+	found := false
+	err := h.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(bucketJobs).Get(key(id))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &r)
+	})
+	if err != nil {
+		logHistory.Error("failed to read job", "job_id", id, "err", err)
+		return r, false
+	}
+	return r, found
+}
+
+// list returns the jobs matching f, most recent first.
+func (h *jobHistory) list(f jobFilter) []jobRecord {
+	var out []jobRecord
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketJobs).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var r jobRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if !f.match(&r) {
+				continue
+			}
+			out = append(out, r)
+			if f.Limit > 0 && len(out) == f.Limit {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logHistory.Error("failed to list jobs", "err", err)
+	}
+	return out
+}
+
+// prune deletes the jobs that are outside the retention policy.
+//
+// The keys are sorted by ID, which is also the order the jobs were started in,
+// so only the oldest jobs need to be looked at.
+func (h *jobHistory) prune(b *bolt.Bucket) error {
+	if h.maxJobs <= 0 && h.maxAge <= 0 {
+		return nil
+	}
+	c := b.Cursor()
+	n := 0
+	if h.maxJobs > 0 {
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			n++
+		}
+	}
+	var stale [][]byte
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if h.maxJobs > 0 && n-len(stale) > h.maxJobs {
+			stale = append(stale, append([]byte(nil), k...))
+			continue
+		}
+		if h.maxAge <= 0 {
+			break
+		}
+		var r jobRecord
+		if err := json.Unmarshal(v, &r); err == nil && time.Since(r.Started) <= h.maxAge {
+			break
+		}
+		stale = append(stale, append([]byte(nil), k...))
+	}
+	for _, k := range stale {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func put(b *bolt.Bucket, r *jobRecord) error {
+	v, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return b.Put(key(r.ID), v)
+}
+
+// key returns the database key for a job ID. Big endian keeps the jobs sorted
+// by ID.
+func key(id int64) []byte {
+	var k [8]byte
+	binary.BigEndian.PutUint64(k[:], uint64(id))
+	return k[:]
+}
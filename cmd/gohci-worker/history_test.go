@@ -0,0 +1,55 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "history.db")
+	h, err := openHistory(f, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		started := time.Now()
+		if i < 2 {
+			started = started.Add(-2 * time.Hour)
+		}
+		if id := h.add(&jobRecord{Org: "o", Repo: "r", State: "pending", Started: started}); id != int64(i+1) {
+			t.Fatalf("add() = %d; not %d", id, i+1)
+		}
+	}
+	h.update(3, func(r *jobRecord) { r.State = "success" })
+	if err = h.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if h, err = openHistory(f, 3, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	defer h.close()
+	l := h.list(jobFilter{})
+	if len(l) != 2 || l[0].ID != 3 || l[1].ID != 2 || l[0].State != "success" {
+		t.Fatalf("unexpected list: %#v", l)
+	}
+	// The pending job didn't survive the restart.
+	if l = h.list(jobFilter{State: "error"}); len(l) != 1 || l[0].ID != 2 || l[0].Note != "interrupted" {
+		t.Fatalf("unexpected list: %#v", l)
+	}
+	if _, ok := h.get(1); ok {
+		t.Fatal("job 1 should have been pruned")
+	}
+	// Adding a job prunes the ones that are too old.
+	if id := h.add(&jobRecord{Started: time.Now()}); id != 4 {
+		t.Fatalf("add() = %d; not 4", id)
+	}
+	if l = h.list(jobFilter{}); len(l) != 2 || l[0].ID != 4 || l[1].ID != 3 {
+		t.Fatalf("unexpected list: %#v", l)
+	}
+}
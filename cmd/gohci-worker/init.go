@@ -0,0 +1,192 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// isInteractive returns true if f is a terminal.
+func isInteractive(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// verifyToken verifies that the OAuth2 token is valid and has the required
+// scopes.
+func verifyToken(token string) error {
+	client := github.NewClient(&http.Client{Transport: &tokenRotator{base: http.DefaultTransport, tokens: []string{token}}})
+	return checkToken(context.Background(), client)
+}
+
+// checkToken is verifyToken with the client to use.
+//
+// Unlike tokenRotator.verifyTokens, it doesn't log, as it is interleaved with
+// the prompts, and the token is refused when it couldn't be verified.
+func checkToken(ctx context.Context, client *github.Client) error {
+	u, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		if resp == nil {
+			return fmt.Errorf("failed to verify the OAuth2 token: %w", err)
+		}
+		return fmt.Errorf("invalid OAuth2 token: %w", err)
+	}
+	// Fine-grained tokens don't report their permissions.
+	if _, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		h := resp.Header.Get("X-OAuth-Scopes")
+		if missing, _ := checkScopes(h, requiredScopes); len(missing) != 0 {
+			return fmt.Errorf("OAuth2 token for %s is missing scopes %s; it has %q", u.GetLogin(), strings.Join(missing, ", "), h)
+		}
+	}
+	return nil
+}
+
+// runInit asks interactively for the settings, writes fileName and prints the
+// webhook to configure.
+//
+// verify is called to validate the OAuth2 token.
+func runInit(fileName string, in io.Reader, out io.Writer, verify func(token string) error) error {
+	r := bufio.NewReader(in)
+	ask := func(q, def string) (string, error) {
+		if def != "" {
+			fmt.Fprintf(out, "%s [%s]: ", q, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", q)
+		}
+		l, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || l == "") {
+			return "", err
+		}
+		if l = strings.TrimSpace(l); l != "" {
+			return l, nil
+		}
+		return def, nil
+	}
+	list := func(s string) []string {
+		var out []string
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+
+	if _, err := os.Stat(fileName); err == nil {
+		a, err := ask(fileName+" exists, overwrite? (y/N)", "")
+		if err != nil {
+			return err
+		}
+		if a != "y" && a != "Y" {
+			return errors.New("aborted")
+		}
+	}
+	c := gohci.DefaultWorkerConfig()
+	var err error
+	if c.WebHookSecret, err = newWebhookSecret(); err != nil {
+		return err
+	}
+	if c.Name, _ = os.Hostname(); c.Name == "" {
+		c.Name = "gohci"
+	}
+	if c.Name, err = ask("Name of the worker, as shown in the commit status", c.Name); err != nil {
+		return err
+	}
+	for {
+		p, err := ask("Port to listen on", strconv.Itoa(c.Port))
+		if err != nil {
+			return err
+		}
+		// Don't offer a refused value as the default.
+		if port, err := strconv.Atoi(p); err == nil && port > 0 && port < 65536 {
+			c.Port = port
+			break
+		}
+		fmt.Fprintf(out, "Invalid port %q\n", p)
+	}
+	fmt.Fprintf(out, "Create an OAuth2 token with the scopes %s at https://github.com/settings/tokens\n", strings.Join(requiredScopes, " and "))
+	for {
+		if c.Oauth2AccessToken, err = ask("OAuth2 token", ""); err != nil {
+			return err
+		}
+		if c.Oauth2AccessToken == "" {
+			continue
+		}
+		if err = verify(c.Oauth2AccessToken); err == nil {
+			break
+		}
+		fmt.Fprintf(out, "%v\n", err)
+	}
+	for {
+		repos, err := ask("Repositories to test, as comma separated org/repo, empty for any", "")
+		if err != nil {
+			return err
+		}
+		c.AllowedRepos = list(repos)
+		bad := ""
+		for _, r := range c.AllowedRepos {
+			if parts := strings.SplitN(r, "/", 2); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				bad = r
+			}
+		}
+		if bad == "" {
+			break
+		}
+		fmt.Fprintf(out, "Invalid repository %q; use \"org/repo\"\n", bad)
+	}
+	base, err := ask("Public URL of the worker", fmt.Sprintf("http://%s:%d/", c.Name, c.Port))
+	if err != nil {
+		return err
+	}
+	hook, err := url.Parse(base)
+	if err != nil || hook.Host == "" {
+		return fmt.Errorf("invalid URL %q", base)
+	}
+	altPath, err := ask("Canonical import path if the repository uses one (altPath), e.g. periph.io/x/gohci", "")
+	if err != nil {
+		return err
+	}
+	superUsers, err := ask("Users allowed to trigger a run with a \"gohci\" comment (superUsers), comma separated", "")
+	if err != nil {
+		return err
+	}
+	// Not escaped, to be readable.
+	var q []string
+	if altPath != "" {
+		q = append(q, "altPath="+altPath)
+	}
+	if u := list(superUsers); len(u) != 0 {
+		q = append(q, "superUsers="+strings.Join(u, ","))
+	}
+	hook.RawQuery = strings.Join(q, "&")
+	// Refuse what the server would refuse.
+	if _, _, err = validateArgs(hook.Query()); err != nil {
+		return err
+	}
+	if err = writeConfig(fileName, c); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "\nWrote %s.\n\n", fileName)
+	fmt.Fprintf(out, "Create a webhook in each repository's settings with:\n")
+	fmt.Fprintf(out, "  Payload URL:  %s\n", hook)
+	fmt.Fprintf(out, "  Content type: application/json\n")
+	fmt.Fprintf(out, "  Secret:       %s\n", c.WebHookSecret)
+	fmt.Fprintf(out, "  Events:       %s\n", strings.Join(hookEvents, ", "))
+	fmt.Fprintf(out, "or start gohci-worker and run:\n")
+	fmt.Fprintf(out, "  gohci-worker -registerwebhooks '%s'\n", hook)
+	return nil
+}
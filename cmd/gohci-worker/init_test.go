@@ -0,0 +1,101 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	yaml "gopkg.in/yaml.v3"
+	"periph.io/x/gohci"
+)
+
+func TestRunInit(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "gohci.yml")
+	in := strings.Join([]string{
+		"rpi",                       // Name.
+		"99999",                     // Invalid port.
+		"8081",                      // Port.
+		"bad",                       // Token refused.
+		"ghp_good",                  // Token.
+		"periph",                    // Invalid repo.
+		"periph/gohci, periph/host", // Repos.
+		"https://ci.example.com/",   // URL.
+		"periph.io/x/gohci",         // altPath.
+		"maruel,alice",              // superUsers.
+	}, "\n") + "\n"
+	out := &bytes.Buffer{}
+	verify := func(tok string) error {
+		if tok != "ghp_good" {
+			return errors.New("invalid OAuth2 token")
+		}
+		return nil
+	}
+	if err := runInit(p, strings.NewReader(in), out, verify); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &gohci.WorkerConfig{}
+	if err = yaml.Unmarshal(b, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "rpi" || c.Port != 8081 || c.Oauth2AccessToken != "ghp_good" || strings.Join(c.AllowedRepos, ",") != "periph/gohci,periph/host" || len(c.WebHookSecret) < 32 || c.Version != gohci.WorkerConfigVersion {
+		t.Fatalf("%+v", c)
+	}
+	o := out.String()
+	for _, s := range []string{"Invalid port \"99999\"\nPort to listen on [8080]: ", "invalid OAuth2 token", "Invalid repository \"periph\"", "Payload URL:  https://ci.example.com/?altPath=periph.io/x/gohci&superUsers=maruel,alice", "Secret:       " + c.WebHookSecret} {
+		if !strings.Contains(o, s) {
+			t.Fatalf("missing %q in:\n%s", s, o)
+		}
+	}
+	// The existing file is not overwritten without confirmation.
+	if err = runInit(p, strings.NewReader("\n"), out, verify); err == nil || err.Error() != "aborted" {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	scopes := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes != "-" {
+			w.Header().Set("X-OAuth-Scopes", scopes)
+		}
+		_, _ = io.WriteString(w, `{"login":"maruel"}`)
+	}))
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	ctx := context.Background()
+	scopes = strings.Join(requiredScopes, ", ")
+	if err := checkToken(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	// Fine-grained token.
+	scopes = "-"
+	if err := checkToken(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	scopes = "gist"
+	if err := checkToken(ctx, client); err == nil || !strings.Contains(err.Error(), "missing scopes") {
+		t.Fatal(err)
+	}
+	// GitHub can't be reached, so nothing was verified.
+	ts.Close()
+	if err := checkToken(ctx, client); err == nil || !strings.Contains(err.Error(), "failed to verify") {
+		t.Fatal(err)
+	}
+}
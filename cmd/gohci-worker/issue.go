@@ -0,0 +1,116 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// breakageTitle is the title of the issue tracking a broken default branch on
+// this worker.
+func (w *workerQueue) breakageTitle() string {
+	return "Build failed on " + w.name
+}
+
+// findBreakage returns the open breakage issue of this worker, if any.
+func (w *workerQueue) findBreakage(org, repo string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		issues, resp, err := w.client.Issues.ListByRepo(w.ctx, org, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range issues {
+			if !i.IsPullRequest() && i.GetTitle() == w.breakageTitle() {
+				return i, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// breakageBody returns the description of a failed job.
+func breakageBody(j *jobRequest, r *jobRecord) string {
+	var b strings.Builder
+	blame := make([]string, len(j.blame))
+	for i, u := range j.blame {
+		blame[i] = "@" + u
+	}
+	fmt.Fprintf(&b, "Commit %s by %s failed.\n", j.commitHash, strings.Join(blame, ", "))
+	if j.firstBad != "" {
+		fmt.Fprintf(&b, "\nFirst bad commit: %s\n", j.firstBad)
+	}
+	var failed []string
+	for _, c := range r.Checks {
+		if !c.Success {
+			failed = append(failed, "- "+c.Name)
+		}
+	}
+	if len(failed) != 0 {
+		fmt.Fprintf(&b, "\nFailed checks:\n%s\n", strings.Join(failed, "\n"))
+	}
+	if r.GistURL != "" {
+		fmt.Fprintf(&b, "\nResults: %s\n", r.GistURL)
+	}
+	return b.String()
+}
+
+// closeBreakage closes the open breakage issue, if any, now that the default
+// branch passes again.
+func (w *workerQueue) closeBreakage(j *jobRequest) {
+	i, err := w.findBreakage(j.org, j.repo)
+	if err != nil {
+		j.log.Error("failed to list issues", "err", err)
+		return
+	}
+	if i == nil {
+		return
+	}
+	body := "Fixed by " + j.commitHash + "."
+	if _, _, err = w.client.Issues.CreateComment(w.ctx, j.org, j.repo, i.GetNumber(), &github.IssueComment{Body: &body}); err != nil {
+		j.log.Error("failed to comment on breakage issue", "issue", i.GetNumber(), "err", err)
+		return
+	}
+	if _, _, err = w.client.Issues.Edit(w.ctx, j.org, j.repo, i.GetNumber(), &github.IssueRequest{State: github.String("closed")}); err != nil {
+		j.log.Error("failed to close breakage issue", "issue", i.GetNumber(), "err", err)
+		return
+	}
+	j.log.Info("closed breakage issue", "issue", i.GetNumber())
+}
+
+// reportBreakage creates an issue for the failed job on the default branch,
+// or adds a comment to the open one.
+func (w *workerQueue) reportBreakage(j *jobRequest) {
+	r, ok := w.h.get(j.id)
+	if !ok {
+		return
+	}
+	body := breakageBody(j, &r)
+	i, err := w.findBreakage(j.org, j.repo)
+	if err != nil {
+		j.log.Error("failed to list issues", "err", err)
+		return
+	}
+	if i != nil {
+		if _, _, err = w.client.Issues.CreateComment(w.ctx, j.org, j.repo, i.GetNumber(), &github.IssueComment{Body: &body}); err != nil {
+			j.log.Error("failed to comment on breakage issue", "issue", i.GetNumber(), "err", err)
+			return
+		}
+		j.log.Info("commented on breakage issue", "issue", i.GetNumber())
+		return
+	}
+	req := &github.IssueRequest{Title: github.String(w.breakageTitle()), Body: &body}
+	if i, _, err = w.client.Issues.Create(w.ctx, j.org, j.repo, req); err != nil {
+		j.log.Error("failed to create breakage issue", "err", err)
+		return
+	}
+	j.log.Info("created breakage issue", "issue", i.GetNumber())
+}
@@ -0,0 +1,16 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestBreakageBody(t *testing.T) {
+	j := &jobRequest{jobSpec: jobSpec{commitHash: "0123456789abcdef", blame: []string{"alice", "bob"}}}
+	r := &jobRecord{GistURL: "https://gist.github.com/x", Checks: []checkResult{{Name: "setup-1-clone", Success: true}, {Name: "cmd1", Success: false}}}
+	want := "Commit 0123456789abcdef by @alice, @bob failed.\n\nFailed checks:\n- cmd1\n\nResults: https://gist.github.com/x\n"
+	if got := breakageBody(j, r); got != want {
+		t.Fatalf("%q != %q", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// lockRegistry serializes the access to named hardware resources, e.g.
+// "spi0" or "camera", across the checks of all the jobs of the worker.
+type lockRegistry struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+func newLockRegistry() *lockRegistry {
+	return &lockRegistry{locks: map[string]chan struct{}{}}
+}
+
+func (l *lockRegistry) get(name string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	c := l.locks[name]
+	if c == nil {
+		c = make(chan struct{}, 1)
+		l.locks[name] = c
+	}
+	return c
+}
+
+// acquire takes all the named locks and returns the function to release
+// them.
+//
+// The locks are always taken in the same order to not deadlock with another
+// check taking an overlapping set. It fails when ctx is canceled while
+// waiting.
+func (l *lockRegistry) acquire(ctx context.Context, names []string) (func(), error) {
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	var held []chan struct{}
+	release := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+	for i, n := range names {
+		if i != 0 && names[i-1] == n {
+			continue
+		}
+		c := l.get(n)
+		select {
+		case c <- struct{}{}:
+			held = append(held, c)
+		case <-ctx.Done():
+			release()
+			return nil, context.Cause(ctx)
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLockRegistry(t *testing.T) {
+	l := newLockRegistry()
+	ctx := context.Background()
+	release, err := l.acquire(ctx, []string{"spi0", "camera", "spi0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An independent resource is available.
+	r2, err := l.acquire(ctx, []string{"i2c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2()
+
+	// An overlapping set waits until canceled.
+	ctx2, cancel := context.WithCancelCause(ctx)
+	cause := errors.New("canceled")
+	cancel(cause)
+	if _, err = l.acquire(ctx2, []string{"camera", "i2c1"}); err != cause {
+		t.Fatalf("unexpected %v", err)
+	}
+	// i2c1 was released on failure.
+	r2, err = l.acquire(ctx, []string{"i2c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2()
+
+	release()
+	r3, err := l.acquire(ctx, []string{"camera"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r3()
+}
@@ -0,0 +1,153 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+
+	"periph.io/x/gohci"
+)
+
+// Loggers per module. They are replaced by setupLogging() once the worker
+// configuration is loaded.
+var (
+	logMain    = slog.Default().With("module", "main")
+	logServer  = slog.Default().With("module", "server")
+	logJob     = slog.Default().With("module", "job")
+	logHistory = slog.Default().With("module", "history")
+)
+
+// logModules lists the modules that can have their own verbosity.
+var logModules = map[string]**slog.Logger{
+	"main":    &logMain,
+	"server":  &logServer,
+	"job":     &logJob,
+	"history": &logHistory,
+}
+
+// setupLogging configures the loggers as specified in the worker config.
+//
+// It must be called before any goroutine is started. The returned function
+// must be called on shutdown to flush the log file, if any.
+func setupLogging(c *gohci.WorkerConfig) (func(), error) {
+	level, err := parseLevel(c.LogLevel)
+	if err != nil {
+		return nil, err
+	}
+	levels := map[string]slog.Level{}
+	for m, v := range c.LogModules {
+		if _, ok := logModules[m]; !ok {
+			return nil, fmt.Errorf("unknown module %q in logmodules; valid modules are %s", m, strings.Join(moduleNames(), ", "))
+		}
+		if levels[m], err = parseLevel(v); err != nil {
+			return nil, err
+		}
+	}
+	var out io.Writer = os.Stderr
+	closer := func() {}
+	if c.LogFile != "" {
+		if c.LogFormat == "journald" || c.LogFormat == "syslog" {
+			return nil, fmt.Errorf("logfile cannot be used with logformat %q", c.LogFormat)
+		}
+		f, err2 := newRotatingFile(c.LogFile, c.LogFileMaxSize, c.LogFileMaxAge, c.LogFileMaxBackups)
+		if err2 != nil {
+			return nil, err2
+		}
+		out = f
+		closer = func() { _ = f.Close() }
+	}
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	switch c.LogFormat {
+	case "", "text":
+		if runtime.GOOS != "windows" && c.LogFile == "" {
+			// systemd and launchd already add a timestamp.
+			opts.ReplaceAttr = dropTime
+		}
+		h = slog.NewTextHandler(out, opts)
+	case "json":
+		h = slog.NewJSONHandler(out, opts)
+	case "journald":
+		if h, err = newJournalHandler(); err != nil {
+			return nil, err
+		}
+	case "syslog":
+		opts.ReplaceAttr = dropTime
+		if h, err = newSyslogHandler(opts); err != nil {
+			return nil, err
+		}
+	default:
+		closer()
+		return nil, fmt.Errorf("invalid logformat %q; use \"text\", \"json\", \"journald\" or \"syslog\"", c.LogFormat)
+	}
+	slog.SetDefault(slog.New(&levelHandler{lvl: level, h: h}))
+	for m, l := range logModules {
+		lvl, ok := levels[m]
+		if !ok {
+			lvl = level
+		}
+		*l = slog.New(&levelHandler{lvl: lvl, h: h}).With("module", m)
+	}
+	return closer, nil
+}
+
+// dropTime removes the timestamp, for when the log sink adds its own.
+func dropTime(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return a
+}
+
+// parseLevel parses one of "debug", "info", "warn" or "error". An empty string
+// means "info".
+func parseLevel(s string) (slog.Level, error) {
+	if s == "" {
+		return slog.LevelInfo, nil
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return l, fmt.Errorf("invalid log level %q; use \"debug\", \"info\", \"warn\" or \"error\"", s)
+	}
+	return l, nil
+}
+
+func moduleNames() []string {
+	out := make([]string, 0, len(logModules))
+	for m := range logModules {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// levelHandler filters records below lvl before forwarding to h.
+type levelHandler struct {
+	lvl slog.Level
+	h   slog.Handler
+}
+
+func (l *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= l.lvl && l.h.Enabled(ctx, level)
+}
+
+func (l *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return l.h.Handle(ctx, r)
+}
+
+func (l *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{lvl: l.lvl, h: l.h.WithAttrs(attrs)}
+}
+
+func (l *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{lvl: l.lvl, h: l.h.WithGroup(name)}
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rotatingFile is an io.Writer to a log file that is rotated once it grows
+// above maxSize bytes or becomes older than maxAge.
+//
+// Rotated files are gzip compressed and only the maxBackups most recent ones
+// are kept, so a worker running for months doesn't fill its SD card.
+type rotatingFile struct {
+	name       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mu         sync.Mutex
+	f          *os.File
+	size       int64
+	opened     time.Time
+	lastBackup time.Time      // Timestamp of the last backup name.
+	compress   sync.WaitGroup // Pending compressions.
+}
+
+func newRotatingFile(name string, maxSize int64, maxAge time.Duration, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{name: name, maxSize: maxSize, maxAge: maxAge, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write implements io.Writer.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if (r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize && r.size != 0) || (r.maxAge > 0 && time.Since(r.opened) > r.maxAge) {
+		if err := r.rotate(); err != nil {
+			// Keep logging to the current file.
+			fmt.Fprintf(os.Stderr, "gohci-worker: failed to rotate %s: %v\n", r.name, err)
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file and waits for pending compressions.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.f.Close()
+	r.compress.Wait()
+	return err
+}
+
+func (r *rotatingFile) open() error {
+	/* #nosec G304 */
+	f, err := os.OpenFile(r.name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f = f
+	r.size = fi.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+// rotate renames the current file and opens a new one. The file is always
+// reopened, even on failure, so the next writes never go to a closed file.
+func (r *rotatingFile) rotate() error {
+	_ = r.f.Close()
+	backup := r.backupName()
+	errRename := os.Rename(r.name, backup)
+	if err := r.open(); err != nil {
+		return err
+	}
+	if errRename != nil {
+		return errRename
+	}
+	r.compress.Add(1)
+	go func() {
+		defer r.compress.Done()
+		if err := gzipFile(backup); err != nil {
+			fmt.Fprintf(os.Stderr, "gohci-worker: failed to compress %s: %v\n", backup, err)
+		}
+		r.prune()
+	}()
+	return nil
+}
+
+// backupName returns an unused name for the next backup.
+//
+// The timestamp makes the backups sort chronologically. It is bumped by one
+// millisecond as needed so two rotations never use the same name.
+func (r *rotatingFile) backupName() string {
+	t := time.Now().UTC().Truncate(time.Millisecond)
+	if !t.After(r.lastBackup) {
+		t = r.lastBackup.Add(time.Millisecond)
+	}
+	for ; ; t = t.Add(time.Millisecond) {
+		b := r.name + "." + t.Format("20060102-150405.000")
+		if _, err := os.Stat(b); !os.IsNotExist(err) {
+			continue
+		}
+		if _, err := os.Stat(b + ".gz"); !os.IsNotExist(err) {
+			continue
+		}
+		r.lastBackup = t
+		return b
+	}
+}
+
+// prune deletes the oldest backups beyond maxBackups.
+func (r *rotatingFile) prune() {
+	if r.maxBackups <= 0 {
+		return
+	}
+	m, err := filepath.Glob(r.name + ".*.gz")
+	if err != nil {
+		return
+	}
+	sort.Strings(m)
+	for len(m) > r.maxBackups {
+		_ = os.Remove(m[0])
+		m = m[1:]
+	}
+}
+
+// gzipFile compresses src to src+".gz" and deletes src.
+func gzipFile(src string) error {
+	/* #nosec G304 */
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	dst := src + ".gz"
+	/* #nosec G304 */
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if err2 := gz.Close(); err == nil {
+		err = err2
+	}
+	if err2 := out.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	_ = in.Close()
+	return os.Remove(src)
+}
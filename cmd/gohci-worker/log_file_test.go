@@ -0,0 +1,89 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "gohci.log")
+	r, err := newRotatingFile(name, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err = io.WriteString(r, "0123456789"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r.prune()
+	m, err := filepath.Glob(name + ".*.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 {
+		t.Fatalf("expected 2 backups, got %v", m)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "0123456789" {
+		t.Fatalf("unexpected content %q", b)
+	}
+}
+
+func TestRotatingFileNames(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "gohci.log")
+	r, err := newRotatingFile(name, 1, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Many rotations in the same millisecond.
+	for i := 0; i < 20; i++ {
+		if _, err = io.WriteString(r, "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := filepath.Glob(name + ".*.gz"); err != nil || len(m) != 19 {
+		t.Fatalf("expected 19 backups, got %v %v", m, err)
+	}
+}
+
+func TestRotatingFileRenameError(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "gohci.log")
+	r, err := newRotatingFile(name, 10, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.WriteString(r, "0123456789"); err != nil {
+		t.Fatal(err)
+	}
+	// The rename fails since the file is gone; the file is reopened anyway.
+	if err = os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = io.WriteString(r, "abc"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(name); err != nil || string(b) != "abcabc" {
+		t.Fatalf("%q %v", b, err)
+	}
+}
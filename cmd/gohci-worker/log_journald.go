@@ -0,0 +1,142 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+)
+
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalHandler is a slog.Handler sending records to journald via its native
+// protocol.
+//
+// Attributes are sent as structured fields, e.g. job_id becomes JOB_ID, so
+// logs can be filtered with "journalctl JOB_ID=42".
+//
+// See https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type journalHandler struct {
+	mu     *sync.Mutex
+	conn   net.Conn
+	prefix string // Group prefix, e.g. "FOO_".
+	attrs  []byte // Preformatted fields from WithAttrs().
+}
+
+func newJournalHandler() (*journalHandler, error) {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journalHandler{mu: &sync.Mutex{}, conn: conn}, nil
+}
+
+func (j *journalHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (j *journalHandler) Handle(ctx context.Context, r slog.Record) error {
+	var b bytes.Buffer
+	appendJournalField(&b, "MESSAGE", r.Message)
+	appendJournalField(&b, "PRIORITY", journalPriority(r.Level))
+	appendJournalField(&b, "SYSLOG_IDENTIFIER", "gohci-worker")
+	b.Write(j.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendJournalAttr(&b, j.prefix, a)
+		return true
+	})
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err := j.conn.Write(b.Bytes())
+	return err
+}
+
+func (j *journalHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b bytes.Buffer
+	b.Write(j.attrs)
+	for _, a := range attrs {
+		appendJournalAttr(&b, j.prefix, a)
+	}
+	return &journalHandler{mu: j.mu, conn: j.conn, prefix: j.prefix, attrs: b.Bytes()}
+}
+
+func (j *journalHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return j
+	}
+	return &journalHandler{mu: j.mu, conn: j.conn, prefix: j.prefix + journalKey(name) + "_", attrs: j.attrs}
+}
+
+// journalPriority maps a slog level to a syslog priority.
+func journalPriority(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return "3"
+	case l >= slog.LevelWarn:
+		return "4"
+	case l >= slog.LevelInfo:
+		return "6"
+	default:
+		return "7"
+	}
+}
+
+func appendJournalAttr(b *bytes.Buffer, prefix string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		p := prefix
+		if a.Key != "" {
+			p += journalKey(a.Key) + "_"
+		}
+		for _, g := range v.Group() {
+			appendJournalAttr(b, p, g)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	appendJournalField(b, prefix+journalKey(a.Key), v.String())
+}
+
+// journalKey converts a key to a valid journald field name: uppercase
+// letters, digits and underscores, not starting with an underscore.
+func journalKey(k string) string {
+	k = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'):
+			return r
+		default:
+			return '_'
+		}
+	}, k)
+	return strings.TrimLeft(k, "_")
+}
+
+// appendJournalField appends a field, using the binary safe encoding when
+// the value contains a new line.
+func appendJournalField(b *bytes.Buffer, k, v string) {
+	b.WriteString(k)
+	if !strings.Contains(v, "\n") {
+		b.WriteByte('=')
+		b.WriteString(v)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	var l [8]byte
+	binary.LittleEndian.PutUint64(l[:], uint64(len(v)))
+	b.Write(l[:])
+	b.WriteString(v)
+	b.WriteByte('\n')
+}
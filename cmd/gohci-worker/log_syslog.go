@@ -0,0 +1,71 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"log/syslog"
+	"strings"
+	"sync"
+)
+
+// syslogHandler is a slog.Handler formatting records as text and sending
+// them to the local syslog daemon with the corresponding priority.
+type syslogHandler struct {
+	s *syslogState
+	h slog.Handler // Writes into s.buf.
+}
+
+type syslogState struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	w   *syslog.Writer
+}
+
+func newSyslogHandler(opts *slog.HandlerOptions) (slog.Handler, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "gohci-worker")
+	if err != nil {
+		return nil, err
+	}
+	s := &syslogState{w: w}
+	return &syslogHandler{s: s, h: slog.NewTextHandler(&s.buf, opts)}, nil
+}
+
+func (s *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.h.Enabled(ctx, level)
+}
+
+func (s *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	s.s.mu.Lock()
+	defer s.s.mu.Unlock()
+	s.s.buf.Reset()
+	if err := s.h.Handle(ctx, r); err != nil {
+		return err
+	}
+	m := strings.TrimSuffix(s.s.buf.String(), "\n")
+	switch {
+	case r.Level >= slog.LevelError:
+		return s.s.w.Err(m)
+	case r.Level >= slog.LevelWarn:
+		return s.s.w.Warning(m)
+	case r.Level >= slog.LevelInfo:
+		return s.s.w.Info(m)
+	default:
+		return s.s.w.Debug(m)
+	}
+}
+
+func (s *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{s: s.s, h: s.h.WithAttrs(attrs)}
+}
+
+func (s *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{s: s.s, h: s.h.WithGroup(name)}
+}
@@ -0,0 +1,17 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+)
+
+func newSyslogHandler(opts *slog.HandlerOptions) (slog.Handler, error) {
+	return nil, errors.New("syslog is not supported on this OS")
+}
@@ -22,26 +22,60 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
+
+	"periph.io/x/gohci/internal/valid"
 )
 
 // runLocal runs the checks run.
-func runLocal(w worker, org, repo, altpath, commitHash string, useSSH bool) error {
-	log.Printf("Running locally")
+func runLocal(w worker, s jobSpec) error {
+	logMain.Info("running locally")
 	// The reason for using the async version is that it creates the status.
-	w.enqueueCheck(org, repo, altpath, commitHash, useSSH, 0, nil)
+	w.enqueueCheck(s)
 	w.wait()
 	// TODO(maruel): Return any error that occurred.
 	return nil
 }
 
+// localHead returns the commit checked out in the directory.
+func localHead(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("-dir %s is not a git checkout: %w", dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 func mainImpl() error {
 	test := flag.String("test", "", "runs a simulation locally, specify the git repository name (not URL) to test, e.g. 'periph/gohci'")
 	alt := flag.String("alt", "", "alt path to use, e.g. 'periph.io/x/gohci'")
 	commit := flag.String("commit", "", "commit SHA1 to test and update; will only update status on github if not 'HEAD'")
+	ref := flag.String("ref", "", "branch or tag to test, e.g. 'release-1.2' or 'refs/tags/v1.0.0'; mutually exclusive with -commit")
 	useSSH := flag.Bool("usessh", false, "use SSH to fetch the repository instead of HTTPS; only necessary when testing")
+	dir := flag.String("dir", "", "already checked out directory to test as-is with -test, without any GitHub access")
+	replay := flag.String("replay", "", "asks the worker running locally to replay the webhook delivery with this GUID")
+	list := flag.Bool("list", false, "lists the recent jobs of the worker running locally")
+	cancel := flag.Int64("cancel", 0, "asks the worker running locally to cancel the pending or running job with this ID")
+	retry := flag.Int64("retry", 0, "asks the worker running locally to run again the job with this ID")
+	pause := flag.Bool("pause", false, "asks the worker running locally to refuse new jobs, e.g. for hardware maintenance; the running job completes")
+	resume := flag.Bool("resume", false, "asks the worker running locally to accept new jobs again after -pause")
+	register := flag.String("registerwebhooks", "", "creates or updates the webhooks of allowedorgs, allowedrepos and pollrepos to point to this URL, e.g. 'https://ci.example.com/', using an admin token from $GOHCI_ADMIN_TOKEN or stdin, then exits")
+	protect := flag.Bool("protectbranches", false, "marks the worker's status as a required check on the default branch of the repositories in allowedrepos and pollrepos, using an admin token from $GOHCI_ADMIN_TOKEN or stdin, then exits")
 	flag.Parse()
+	// Useful in a container, where the config is usually mounted read-only.
+	fileName := os.Getenv("GOHCI_CONFIG")
+	if fileName == "" {
+		fileName = "gohci.yml"
+	}
+	if flag.NArg() != 0 {
+		if flag.NArg() != 1 || flag.Arg(0) != "init" {
+			return fmt.Errorf("unknown arguments %q; only \"init\" is supported", flag.Args())
+		}
+		return runInit(fileName, os.Stdin, os.Stdout, verifyToken)
+	}
 	if runtime.GOOS != "windows" {
 		log.SetFlags(0)
 	}
@@ -49,37 +83,127 @@ func mainImpl() error {
 		if len(*commit) != 0 {
 			return errors.New("-commit doesn't make sense without -test")
 		}
+		if len(*ref) != 0 {
+			return errors.New("-ref doesn't make sense without -test")
+		}
 		if len(*alt) != 0 {
 			return errors.New("-alt doesn't make sense without -test")
 		}
 		if *useSSH {
 			return errors.New("-usessh doesn't make sense without -test")
 		}
+		if len(*dir) != 0 {
+			return errors.New("-dir doesn't make sense without -test")
+		}
 	} else {
 		if strings.HasPrefix(*test, "github.com/") {
 			return errors.New("don't prefix -test value with 'github.com/', it is already assumed")
 		}
+		if len(*ref) != 0 {
+			if len(*commit) != 0 {
+				return errors.New("-ref and -commit are mutually exclusive")
+			}
+			if !valid.Ref(*ref) {
+				return fmt.Errorf("invalid -ref %q", *ref)
+			}
+		}
+		if len(*dir) != 0 && (len(*commit) != 0 || len(*ref) != 0 || *useSSH) {
+			return errors.New("-dir is mutually exclusive with -commit, -ref and -usessh")
+		}
 	}
 	defer func() {
-		log.Printf("Shutting down")
+		logMain.Info("shutting down")
 	}()
-	fileName := "gohci.yml"
+	if _, err := os.Stat(fileName); os.IsNotExist(err) && isInteractive(os.Stdin) {
+		return runInit(fileName, os.Stdin, os.Stdout, verifyToken)
+	}
 	c, err := loadConfig(fileName)
 	if err != nil {
 		return err
 	}
-	log.Printf("Built with %s", runtime.Version())
-	log.Printf("Config: %#v", c)
+	closeLog, err := setupLogging(c)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	if err = applyProxy(c); err != nil {
+		return err
+	}
+	logMain.Info("starting", "go", runtime.Version(), "name", c.Name, "port", c.Port)
+	if len(*replay) != 0 {
+		return replayDelivery(c.Port, c.WebHookSecret, *replay)
+	}
+	if *list {
+		return listJobs(c.Port, c.WebHookSecret)
+	}
+	if *cancel != 0 {
+		return jobAction(c.Port, c.WebHookSecret, *cancel, "cancel")
+	}
+	if *retry != 0 {
+		return jobAction(c.Port, c.WebHookSecret, *retry, "retry")
+	}
+	if *pause && *resume {
+		return errors.New("-pause and -resume are mutually exclusive")
+	}
+	if *pause {
+		return pauseWorker(c.Port, c.WebHookSecret, "pause")
+	}
+	if *resume {
+		return pauseWorker(c.Port, c.WebHookSecret, "resume")
+	}
+	if *register != "" {
+		return registerWebhooks(c, *register)
+	}
+	if *protect {
+		return protectBranches(c)
+	}
 	wd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
-	w := newWorkerQueue(c.Name, wd, c.Oauth2AccessToken)
+	h, err := openHistory(filepath.Join(wd, "history.db"), c.HistoryMaxJobs, c.HistoryMaxAge)
+	if err != nil {
+		return err
+	}
+	defer h.close()
+	if c.Coordinator {
+		if len(*test) != 0 {
+			return errors.New("-test doesn't make sense on a coordinator")
+		}
+		return runServer(c, nil, h, fileName)
+	}
+	w, err := newWorkerQueue(c, wd, h, len(*dir) == 0)
+	if err != nil {
+		return err
+	}
 	if len(*test) != 0 {
 		parts := strings.SplitN(*test, "/", 2)
-		return runLocal(w, parts[0], parts[1], *alt, *commit, *useSSH)
+		s := jobSpec{org: parts[0], repo: parts[1], altPath: *alt, commitHash: *commit, ref: *ref, useSSH: *useSSH, event: "test"}
+		if len(*dir) != 0 {
+			if s.localDir, err = filepath.Abs(*dir); err != nil {
+				return err
+			}
+			if s.commitHash, err = localHead(s.localDir); err != nil {
+				return err
+			}
+			s.local = true
+		}
+		return runLocal(w, s)
+	}
+	go handlePauseSignal(w)
+	if len(c.GitRemotes) != 0 {
+		go pollRemotes(c, w)
+	}
+	if len(c.WarmRepos) != 0 {
+		go runWarmer(c, w)
+	}
+	if c.GistMaxAge > 0 || c.GistMaxPerRepo > 0 {
+		go runGistCleaner(w)
+	}
+	if len(c.PollRepos) != 0 {
+		return runPoller(c, w, fileName)
 	}
-	return runServer(c, w, fileName)
+	return runServer(c, w, h, fileName)
 }
 
 func main() {
@@ -0,0 +1,86 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// gistSummaryFile is the markdown summary of a job. It sorts before the
+	// other files and is rendered by GitHub.
+	gistSummaryFile = "_summary.md"
+	// markdownMaxOutput is the maximum output of a single check in the
+	// summary. Only the end is kept, the whole output is in its own file.
+	markdownMaxOutput = 64 * 1024
+)
+
+// mdResult is one step of a job rendered in the markdown summary.
+type mdResult struct {
+	name    string
+	content string
+	success bool
+	d       time.Duration
+}
+
+// renderMarkdown returns a markdown summary of the job with a collapsible
+// section per step. The failed steps are expanded.
+func renderMarkdown(title string, results []mdResult, d time.Duration) string {
+	var b strings.Builder
+	failed := 0
+	for _, r := range results {
+		if !r.success {
+			failed++
+		}
+	}
+	if failed == 0 {
+		fmt.Fprintf(&b, "## ✅ %s\n\n%d/%d steps succeeded in %s.\n", title, len(results), len(results), roundDuration(d))
+	} else {
+		fmt.Fprintf(&b, "## ❌ %s\n\n%d of %d steps failed in %s.\n", title, failed, len(results), roundDuration(d))
+	}
+	for _, r := range results {
+		icon, open := "✅", ""
+		if !r.success {
+			icon, open = "❌", " open"
+		}
+		c := r.content
+		if len(c) > markdownMaxOutput {
+			// Don't cut a rune in half.
+			i := len(c) - markdownMaxOutput
+			for i < len(c) && !utf8.RuneStart(c[i]) {
+				i++
+			}
+			c = "<truncated>\n" + c[i:]
+		}
+		fence := codeFence(c)
+		fmt.Fprintf(&b, "\n<details%s><summary>%s %s in %s</summary>\n\n%s\n%s", open, icon, r.name, roundDuration(r.d), fence, c)
+		if !strings.HasSuffix(c, "\n") {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s\n\n</details>\n", fence)
+	}
+	return b.String()
+}
+
+// codeFence returns a code fence longer than any run of backticks in s.
+func codeFence(s string) string {
+	longest, cur := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			if cur++; cur > longest {
+				longest = cur
+			}
+		} else {
+			cur = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	results := []mdResult{
+		{"setup-1-clone", "cloned\n", true, time.Second},
+		{"cmd1", "--- FAIL: TestFoo\n", false, 2 * time.Second},
+	}
+	got := renderMarkdown("w for periph/gohci", results, 3*time.Second)
+	want := "## ❌ w for periph/gohci\n\n1 of 2 steps failed in 3s.\n" +
+		"\n<details><summary>✅ setup-1-clone in 1s</summary>\n\n```\ncloned\n```\n\n</details>\n" +
+		"\n<details open><summary>❌ cmd1 in 2s</summary>\n\n```\n--- FAIL: TestFoo\n```\n\n</details>\n"
+	if got != want {
+		t.Fatalf("%q", got)
+	}
+	if got := renderMarkdown("t", results[:1], time.Second); !strings.HasPrefix(got, "## ✅ t\n\n1/1 steps succeeded") {
+		t.Fatalf("%q", got)
+	}
+	// The truncation doesn't cut a rune in half.
+	long := "é" + strings.Repeat("a", markdownMaxOutput-2) + "\n"
+	got = renderMarkdown("t", []mdResult{{"cmd1", long, true, time.Second}}, time.Second)
+	if !utf8.ValidString(got) || !strings.Contains(got, "```\n<truncated>\n"+long[2:]+"```") {
+		t.Fatal("invalid truncation")
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	data := []struct{ in, want string }{
+		{"", "```"},
+		{"a `b` ``c``", "```"},
+		{"```go\n```", "````"},
+		{"``````", "```````"},
+	}
+	for _, l := range data {
+		if got := codeFence(l.in); got != l.want {
+			t.Fatalf("%q: %q", l.in, got)
+		}
+	}
+}
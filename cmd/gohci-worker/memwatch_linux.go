@@ -0,0 +1,52 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// groupRSS returns the resident memory in bytes of the processes in the
+// process group.
+func groupRSS(pgid int) (int64, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return 0, err
+	}
+	total := int64(0)
+	for _, p := range stats {
+		/* #nosec G304 */
+		b, err := os.ReadFile(p)
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		if pgrp, rss, ok := parseProcStat(string(b)); ok && pgrp == pgid {
+			total += rss * int64(os.Getpagesize())
+		}
+	}
+	return total, nil
+}
+
+// parseProcStat returns the process group and the resident set size in pages
+// from the content of /proc/<pid>/stat.
+func parseProcStat(s string) (int, int64, bool) {
+	// The command name may contain spaces and parenthesis.
+	i := strings.LastIndexByte(s, ')')
+	if i == -1 {
+		return 0, 0, false
+	}
+	// The fields after the name start at the third one, the state.
+	f := strings.Fields(s[i+1:])
+	if len(f) < 22 {
+		return 0, 0, false
+	}
+	pgrp, err1 := strconv.Atoi(f[2])
+	rss, err2 := strconv.ParseInt(f[21], 10, 64)
+	return pgrp, rss, err1 == nil && err2 == nil
+}
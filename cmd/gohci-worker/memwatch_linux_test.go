@@ -0,0 +1,35 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseProcStat(t *testing.T) {
+	const s = "1234 (go test (x)) S 1000 1234 1234 0 -1 4194560 100 0 0 0 5 1 0 0 20 0 8 0 500 1000000 2500 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 2 0 0 0 0 0\n"
+	pgrp, rss, ok := parseProcStat(s)
+	if !ok || pgrp != 1234 || rss != 2500 {
+		t.Fatal(pgrp, rss, ok)
+	}
+	if _, _, ok := parseProcStat("1234 (x) S 1"); ok {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestRunMemoryLimit(t *testing.T) {
+	j := newJobRequest(jobSpec{org: "periph", repo: "gohci"}, t.TempDir())
+	if err := os.MkdirAll(j.gopath, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// Any process uses more than 1 byte.
+	j.memoryLimit = 1
+	out, ok := j.run("", nil, []string{"sleep", "10"}, false)
+	if ok || !strings.Contains(out, "exceeded memory limit") {
+		t.Fatalf("%t %q", ok, out)
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+// groupRSS is not implemented on this OS.
+func groupRSS(pgid int) (int64, error) {
+	return 0, errors.New("not implemented")
+}
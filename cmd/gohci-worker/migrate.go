@@ -0,0 +1,129 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+	"periph.io/x/gohci"
+)
+
+// migrateConfig upgrades a worker configuration to gohci.WorkerConfigVersion.
+//
+// It returns the upgraded configuration, keeping the comments, and the
+// version it was upgraded from. The version is gohci.WorkerConfigVersion when b
+// didn't need to be upgraded.
+func migrateConfig(b []byte) ([]byte, int, error) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, 0, err
+	}
+	if len(doc.Content) == 0 {
+		// Empty file.
+		return b, gohci.WorkerConfigVersion, nil
+	}
+	m := doc.Content[0]
+	if m.Kind != yaml.MappingNode {
+		return nil, 0, errors.New("the configuration must be a mapping")
+	}
+	v := 0
+	if n := mapValue(m, "version"); n != nil {
+		if err := n.Decode(&v); err != nil {
+			return nil, 0, fmt.Errorf("invalid version: %w", err)
+		}
+	}
+	switch {
+	case v == gohci.WorkerConfigVersion:
+		return b, v, nil
+	case v > gohci.WorkerConfigVersion:
+		return nil, 0, fmt.Errorf("version %d is newer than the version %d supported by this gohci-worker; upgrade it", v, gohci.WorkerConfigVersion)
+	case v < 0:
+		return nil, 0, fmt.Errorf("invalid version %d", v)
+	}
+	if err := migrateV0(m); err != nil {
+		return nil, 0, err
+	}
+	setMapValue(m, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(gohci.WorkerConfigVersion)})
+	out, err := yaml.Marshal(&doc)
+	return out, v, err
+}
+
+// migrateV0 upgrades the v0 layout, where the repositories to test were listed
+// in "projects" along their checks.
+//
+// The repositories are added to allowedrepos. The ones with checks stay in
+// projects with "checksmode: override", since in v0 the worker's checks were
+// run instead of the repository's.
+func migrateV0(m *yaml.Node) error {
+	p := mapValue(m, "projects")
+	if p == nil {
+		return nil
+	}
+	var projects []struct {
+		Name   string
+		Checks []yaml.Node
+	}
+	if err := p.Decode(&projects); err != nil {
+		return fmt.Errorf("invalid projects: %w", err)
+	}
+	repos := mapValue(m, "allowedrepos")
+	if repos == nil {
+		repos = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		setMapValue(m, "allowedrepos", repos)
+	}
+	var withChecks []*yaml.Node
+	for i, pr := range projects {
+		repos.Content = append(repos.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pr.Name})
+		if len(pr.Checks) != 0 {
+			n := p.Content[i]
+			deleteMapValue(n, "checksmode")
+			n.Content = append(n.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "checksmode"},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "override"})
+			withChecks = append(withChecks, n)
+		}
+	}
+	if len(withChecks) == 0 {
+		deleteMapValue(m, "projects")
+	} else {
+		p.Content = withChecks
+	}
+	return nil
+}
+
+// mapValue returns the value of the key in the mapping node, or nil.
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMapValue sets the value of the key in the mapping node, adding it first
+// if not present.
+func setMapValue(m *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = v
+			return
+		}
+	}
+	k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	m.Content = append([]*yaml.Node{k, v}, m.Content...)
+}
+
+// deleteMapValue removes the key from the mapping node.
+func deleteMapValue(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
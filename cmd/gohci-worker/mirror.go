@@ -0,0 +1,92 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// mirrorPath returns the path of the bare mirror of the repository in dir.
+func mirrorPath(dir string, s jobSpec) string {
+	return filepath.Join(dir, s.org+"_"+s.repo+".git")
+}
+
+// fetchMirror creates the bare mirror if needed, fetches the commit into it
+// and returns the commit's hash.
+func (j *jobRequest) fetchMirror(mirror, sha string) (string, string, bool) {
+	if j.locks != nil {
+		// Jobs of the same repository share the mirror and its FETCH_HEAD.
+		release, err := j.locks.acquire(j.ctx, []string{"mirror:" + mirror})
+		if err != nil {
+			return err.Error() + "\n", "", false
+		}
+		defer release()
+	}
+	// Creates the GOPATH, the commands run from there.
+	if err := j.assertDir(); err != nil {
+		return err.Error() + "\n", "", false
+	}
+	out := ""
+	var cmds [][]string
+	if _, err := os.Stat(mirror); err != nil {
+		cmds = append(cmds,
+			[]string{"git", "init", "--quiet", "--bare", mirror},
+			[]string{"git", "-C", mirror, "remote", "add", "origin", j.cloneURL()})
+	} else {
+		cmds = append(cmds,
+			[]string{"git", "-C", mirror, "remote", "set-url", "origin", j.cloneURL()},
+			// The worktrees of the previous jobs were deleted by cleanup().
+			[]string{"git", "-C", mirror, "worktree", "prune"})
+	}
+	// Never shallow, so the history is kept for the next jobs.
+	cmds = append(cmds, []string{"git", "-C", mirror, "fetch", "--quiet", "origin", sha})
+	for _, c := range cmds {
+		stdout, ok := j.run("", nil, c, false)
+		out += stdout
+		if !ok {
+			return out, "", false
+		}
+	}
+	// The output of run() is decorated, so call git directly.
+	/* #nosec G204 */
+	c := exec.Command("git", "-C", mirror, "rev-parse", "FETCH_HEAD")
+	c.Env = j.env
+	b, err := c.CombinedOutput()
+	if err != nil {
+		return out + string(b) + err.Error() + "\n", "", false
+	}
+	return out, strings.TrimSpace(string(b)), true
+}
+
+// checkoutWorktree checks out the commit as a worktree of the repository's
+// bare mirror, which keeps the objects between jobs.
+func (j *jobRequest) checkoutWorktree(p, sha string) (string, bool) {
+	out, commit, ok := j.fetchMirror(j.mirror, sha)
+	if !ok {
+		return out, false
+	}
+	stdout, ok := j.run("", nil, []string{"git", "-C", j.mirror, "worktree", "add", "--quiet", "--detach", "--force", filepath.Join(j.gopath, p), commit}, false)
+	return out + stdout, ok
+}
+
+// useReference updates the reference mirror and makes the checkout borrow
+// its objects, like "git clone --reference".
+func (j *jobRequest) useReference(p, sha string) (string, bool) {
+	out, _, ok := j.fetchMirror(j.reference, sha)
+	if !ok {
+		return out, false
+	}
+	alt := filepath.Join(j.gopath, p, ".git", "objects", "info", "alternates")
+	if err := os.MkdirAll(filepath.Dir(alt), 0o700); err != nil {
+		return out + err.Error() + "\n", false
+	}
+	if err := os.WriteFile(alt, []byte(filepath.Join(j.reference, "objects")+"\n"), 0o600); err != nil {
+		return out + err.Error() + "\n", false
+	}
+	return out + "Using reference " + j.reference + "\n", true
+}
@@ -0,0 +1,63 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckoutWorktree(t *testing.T) {
+	origin, commits := newTestOrigin(t)
+	wd := t.TempDir()
+	locks := newLockRegistry()
+	root := filepath.Join(wd, "lab_fw", "src", "remote", "lab", "fw")
+	for _, commit := range commits {
+		s := jobSpec{org: "lab", repo: "fw", remoteURL: "file://" + origin, commitHash: commit, local: true}
+		j := newJobRequest(s, wd)
+		j.mirror = mirrorPath(filepath.Join(wd, "mirrors"), s)
+		j.locks = locks
+		results := make(chan gistFile, 2)
+		j.cleanup("precleanup", results)
+		if out, ok := j.checkout(); !ok {
+			t.Fatalf("checkout failed:\n%s", out)
+		}
+		if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "file")); err != nil || string(b) != "two" {
+		t.Fatalf("%q %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(wd, "mirrors", "lab_fw.git", "HEAD")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckoutReference(t *testing.T) {
+	origin, commits := newTestOrigin(t)
+	wd := t.TempDir()
+	ref := t.TempDir()
+	locks := newLockRegistry()
+	root := filepath.Join(wd, "lab_fw", "src", "remote", "lab", "fw")
+	for _, commit := range commits {
+		s := jobSpec{org: "lab", repo: "fw", remoteURL: "file://" + origin, commitHash: commit, local: true}
+		j := newJobRequest(s, wd)
+		j.reference = mirrorPath(ref, s)
+		j.locks = locks
+		results := make(chan gistFile, 2)
+		j.cleanup("precleanup", results)
+		if out, ok := j.checkout(); !ok {
+			t.Fatalf("checkout failed:\n%s", out)
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "file")); err != nil || string(b) != "two" {
+		t.Fatalf("%q %v", b, err)
+	}
+	if b, err := os.ReadFile(filepath.Join(root, ".git", "objects", "info", "alternates")); err != nil || string(b) != filepath.Join(ref, "lab_fw.git", "objects")+"\n" {
+		t.Fatalf("%q %v", b, err)
+	}
+}
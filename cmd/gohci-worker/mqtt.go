@@ -0,0 +1,128 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+)
+
+// mqttPublish publishes a single message to an MQTT broker with QoS 0.
+//
+// It implements the bare minimum of MQTT 3.1.1 to not need a dependency:
+// connect, publish and disconnect. broker is "tcp://host[:1883]" or
+// "ssl://host[:8883]".
+//
+// http://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html
+func mqttPublish(broker, user, password, clientID, topic string, payload []byte, retain bool) error {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return err
+	}
+	d := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		conn, err = d.Dial("tcp", withDefaultPort(u.Host, "1883"))
+	case "ssl", "tls", "mqtts":
+		conn, err = tls.DialWithDialer(d, "tcp", withDefaultPort(u.Host, "8883"), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return fmt.Errorf("unsupported mqtt broker scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(30 * time.Second)); err != nil {
+		return err
+	}
+
+	// CONNECT with a clean session.
+	var b bytes.Buffer
+	writeMQTTString(&b, "MQTT")
+	b.WriteByte(4) // Protocol level 3.1.1.
+	flags := byte(0x02)
+	if user != "" {
+		flags |= 0x80
+	}
+	if password != "" {
+		flags |= 0x40
+	}
+	b.WriteByte(flags)
+	b.Write([]byte{0, 60}) // Keep alive in seconds.
+	writeMQTTString(&b, clientID)
+	if user != "" {
+		writeMQTTString(&b, user)
+	}
+	if password != "" {
+		writeMQTTString(&b, password)
+	}
+	if err = writeMQTTPacket(conn, 0x10, b.Bytes()); err != nil {
+		return err
+	}
+	ack := make([]byte, 4)
+	if _, err = io.ReadFull(conn, ack); err != nil {
+		return err
+	}
+	if ack[0] != 0x20 || ack[1] != 2 {
+		return errors.New("mqtt: unexpected reply to CONNECT")
+	}
+	if ack[3] != 0 {
+		return fmt.Errorf("mqtt: connection refused with code %d", ack[3])
+	}
+
+	// PUBLISH with QoS 0, which has no packet identifier nor acknowledgement.
+	b.Reset()
+	writeMQTTString(&b, topic)
+	b.Write(payload)
+	h := byte(0x30)
+	if retain {
+		h |= 0x01
+	}
+	if err = writeMQTTPacket(conn, h, b.Bytes()); err != nil {
+		return err
+	}
+	return writeMQTTPacket(conn, 0xE0, nil)
+}
+
+// withDefaultPort adds the port to host if it has none.
+func withDefaultPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// writeMQTTString writes a length prefixed UTF-8 string.
+func writeMQTTString(b *bytes.Buffer, s string) {
+	b.WriteByte(byte(len(s) >> 8))
+	b.WriteByte(byte(len(s)))
+	b.WriteString(s)
+}
+
+// writeMQTTPacket writes the fixed header, including the variable length
+// encoded remaining length, and the rest of the packet.
+func writeMQTTPacket(w io.Writer, header byte, rest []byte) error {
+	p := []byte{header}
+	l := len(rest)
+	for {
+		d := byte(l % 128)
+		if l /= 128; l > 0 {
+			d |= 0x80
+		}
+		p = append(p, d)
+		if l == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(p, rest...))
+	return err
+}
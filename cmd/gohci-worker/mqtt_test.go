@@ -0,0 +1,67 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestMQTTPublish(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	got := make(chan []byte, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			got <- nil
+			return
+		}
+		defer c.Close()
+		if readPacket(c) == nil {
+			got <- nil
+			return
+		}
+		_, _ = c.Write([]byte{0x20, 2, 0, 0})
+		got <- readPacket(c)
+	}()
+	if err := mqttPublish("tcp://"+l.Addr().String(), "u", "p", "id", "a/b", []byte("hi"), true); err != nil {
+		t.Fatal(err)
+	}
+	// Header with retain, then topic then payload.
+	if p, want := <-got, []byte{0x31, 0, 3, 'a', '/', 'b', 'h', 'i'}; !bytes.Equal(p, want) {
+		t.Fatalf("%v != %v", p, want)
+	}
+}
+
+// readPacket reads a whole MQTT packet, assuming a single byte length, and
+// returns it without the length.
+func readPacket(r io.Reader) []byte {
+	h := make([]byte, 2)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil
+	}
+	p := make([]byte, h[1])
+	if _, err := io.ReadFull(r, p); err != nil {
+		return nil
+	}
+	return append([]byte{h[0]}, p...)
+}
+
+func TestWriteMQTTPacket(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeMQTTPacket(&b, 0x30, make([]byte, 321)); err != nil {
+		t.Fatal(err)
+	}
+	// 321 = 0x41 + 2*128.
+	if h := b.Bytes()[:3]; !bytes.Equal(h, []byte{0x30, 0xC1, 0x02}) {
+		t.Fatalf("%x", h)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+
+	"periph.io/x/gohci"
+)
+
+// niceCmd wraps cmd with nice and ionice per the priorities.
+//
+// ionice is "idle" or "best-effort". It is Linux only and ignored elsewhere.
+// Both are ignored on Windows.
+func niceCmd(goos string, nice int, ionice string, cmd []string) []string {
+	if goos == "windows" {
+		return cmd
+	}
+	var out []string
+	if ionice != "" && goos == "linux" {
+		class := "3"
+		if ionice == "best-effort" {
+			class = "2"
+		}
+		out = append(out, "ionice", "-c", class)
+	}
+	if nice != 0 {
+		out = append(out, "nice", "-n", strconv.Itoa(nice))
+	}
+	if len(out) == 0 {
+		return cmd
+	}
+	return append(out, cmd...)
+}
+
+// pinCmd wraps cmd with taskset to run on the CPUs only. It is Linux only
+// and ignored elsewhere.
+func pinCmd(goos string, cpus []int, cmd []string) []string {
+	if len(cpus) == 0 || goos != "linux" {
+		return cmd
+	}
+	l := make([]string, len(cpus))
+	for i, c := range cpus {
+		l[i] = strconv.Itoa(c)
+	}
+	return append([]string{"taskset", "-c", strings.Join(l, ",")}, cmd...)
+}
+
+// checkPriority returns the command of the check with its priority and CPU
+// affinity applied.
+func (j *jobRequest) checkPriority(c *gohci.Check, cmd []string) []string {
+	nice := j.nice
+	if c.Nice != nil {
+		nice = *c.Nice
+	}
+	ionice := c.IONice
+	if ionice == "" {
+		ionice = j.ionice
+	}
+	return niceCmd(runtime.GOOS, nice, ionice, pinCmd(runtime.GOOS, c.CPUs, cmd))
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestPinCmd(t *testing.T) {
+	cmd := []string{"go", "test", "-bench", "."}
+	if got := pinCmd("linux", nil, cmd); !reflect.DeepEqual(got, cmd) {
+		t.Fatalf("%q", got)
+	}
+	want := []string{"taskset", "-c", "4,5", "go", "test", "-bench", "."}
+	if got := pinCmd("linux", []int{4, 5}, cmd); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%q", got)
+	}
+	if got := pinCmd("darwin", []int{4, 5}, cmd); !reflect.DeepEqual(got, cmd) {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestNiceCmd(t *testing.T) {
+	cmd := []string{"go", "test", "./..."}
+	data := []struct {
+		goos   string
+		nice   int
+		ionice string
+		want   []string
+	}{
+		{"linux", 0, "", cmd},
+		{"linux", 10, "", []string{"nice", "-n", "10", "go", "test", "./..."}},
+		{"linux", 10, "idle", []string{"ionice", "-c", "3", "nice", "-n", "10", "go", "test", "./..."}},
+		{"linux", 0, "best-effort", []string{"ionice", "-c", "2", "go", "test", "./..."}},
+		{"darwin", 5, "idle", []string{"nice", "-n", "5", "go", "test", "./..."}},
+		{"windows", 5, "idle", cmd},
+	}
+	for i, l := range data {
+		if got := niceCmd(l.goos, l.nice, l.ionice, cmd); !reflect.DeepEqual(got, l.want) {
+			t.Fatalf("#%d: %q != %q", i, got, l.want)
+		}
+	}
+}
+
+func TestCheckPriority(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("nice is ignored on Windows")
+	}
+	cmd := []string{"go", "test"}
+	j := &jobRequest{nice: 10}
+	if got, want := j.checkPriority(&gohci.Check{}, cmd), []string{"nice", "-n", "10", "go", "test"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("%q", got)
+	}
+	// A check can reset the worker's niceness.
+	zero := 0
+	if got := j.checkPriority(&gohci.Check{Nice: &zero}, cmd); !reflect.DeepEqual(got, cmd) {
+		t.Fatalf("%q", got)
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// notifyClient is the HTTP client used by the notifiers.
+var notifyClient = &http.Client{Timeout: 30 * time.Second}
+
+// jobEvent is a job lifecycle event sent to the notifiers.
+type jobEvent struct {
+	Event         string // "started" or "finished"
+	Worker        string
+	DefaultBranch bool // Set when the job is for a push to the default branch.
+	jobRecord
+}
+
+// summary returns a one line summary of the event, e.g.
+// "periph/gohci@0123456789ab (PR #12) success in 1m2s on worker".
+func (e *jobEvent) summary() string {
+	c := e.Commit
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	s := e.Org + "/" + e.Repo + "@" + c
+	if e.PullID != 0 {
+		s += fmt.Sprintf(" (PR #%d)", e.PullID)
+	}
+	if e.Event == "started" {
+		s += " started"
+	} else {
+		s += " " + e.State + " in " + roundDuration(e.Duration).String()
+	}
+	return s + " on " + e.Worker
+}
+
+// matchRepos returns true if the "org" or "org/repo" entries match. An empty
+// list matches everything.
+func matchRepos(repos []string, org, repo string) bool {
+	if len(repos) == 0 {
+		return true
+	}
+	for _, r := range repos {
+		if strings.EqualFold(r, org) || strings.EqualFold(r, org+"/"+repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends the event of the job to the matching notifications.
+//
+// It is asynchronous and best effort. wait() waits for the notifications to
+// be sent.
+func (w *workerQueue) notify(id int64, event string) {
+	if len(w.c.Notifications) == 0 {
+		return
+	}
+	r, ok := w.h.get(id)
+	if !ok {
+		return
+	}
+	e := &jobEvent{Event: event, Worker: w.name, jobRecord: r}
+	if r.PullID == 0 && !r.Local && strings.HasPrefix(r.Ref, "refs/heads/") {
+		e.DefaultBranch = r.Ref == "refs/heads/"+w.defaultBranch(r.Org, r.Repo)
+	}
+	for i := range w.c.Notifications {
+		n := &w.c.Notifications[i]
+		if !matchRepos(n.Repos, r.Org, r.Repo) {
+			continue
+		}
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			sendNotification(w.c, n, e)
+		}()
+	}
+}
+
+// sendNotification sends the event to each of the services configured in n.
+func sendNotification(c *gohci.WorkerConfig, n *gohci.Notification, e *jobEvent) {
+	if n.Slack != "" {
+		if err := postSlack(n.Slack, e); err != nil {
+			logJob.Error("failed to notify slack", "job_id", e.ID, "err", err)
+		}
+	}
+	if n.MatrixRoom != "" && e.Event == "finished" {
+		if err := postMatrix(n, e); err != nil {
+			logJob.Error("failed to notify matrix", "job_id", e.ID, "err", err)
+		}
+	}
+	if n.Webhook != "" && e.Event == "finished" {
+		if err := postWebhook(n, e); err != nil {
+			logJob.Error("failed to notify webhook", "job_id", e.ID, "err", err)
+		}
+	}
+	if n.MQTTBroker != "" {
+		if err := publishMQTT(n, e); err != nil {
+			logJob.Error("failed to publish to mqtt", "job_id", e.ID, "err", err)
+		}
+	}
+	if len(n.Email) != 0 && e.Event == "finished" && e.State == "failure" && e.DefaultBranch {
+		if err := sendEmail(c, n.Email, e); err != nil {
+			logJob.Error("failed to send email", "job_id", e.ID, "err", err)
+		}
+	}
+}
+
+// failedChecks returns the names of the checks that failed.
+func (e *jobEvent) failedChecks() []string {
+	var out []string
+	for _, c := range e.Checks {
+		if !c.Success {
+			out = append(out, c.Name)
+		}
+	}
+	return out
+}
+
+// sendEmail emails the failure of a push to the default branch.
+func sendEmail(c *gohci.WorkerConfig, to []string, e *jobEvent) error {
+	if c.SMTPServer == "" {
+		return errors.New("smtpserver is not set")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.SMTPFrom)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: Build %q failed on %s/%s\r\n", e.Worker, e.Org, e.Repo)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\n", e.summary())
+	fmt.Fprintf(&b, "Branch: %s\r\n", strings.TrimPrefix(e.Ref, "refs/heads/"))
+	fmt.Fprintf(&b, "Commit: https://github.com/%s/%s/commit/%s\r\n", e.Org, e.Repo, e.Commit)
+	if f := e.failedChecks(); len(f) != 0 {
+		fmt.Fprintf(&b, "Failed: %s\r\n", strings.Join(f, ", "))
+	}
+	if e.GistURL != "" {
+		fmt.Fprintf(&b, "Results: %s\r\n", e.GistURL)
+	}
+	var auth smtp.Auth
+	if c.SMTPUser != "" {
+		host, _, err := net.SplitHostPort(c.SMTPServer)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, host)
+	}
+	return smtp.SendMail(c.SMTPServer, auth, c.SMTPFrom, to, []byte(b.String()))
+}
+
+// postSlack posts the event to a Slack incoming webhook.
+//
+// https://api.slack.com/messaging/webhooks
+func postSlack(u string, e *jobEvent) error {
+	icon := ":hourglass_flowing_sand:"
+	switch e.State {
+	case "success":
+		icon = ":white_check_mark:"
+	case "failure":
+		icon = ":x:"
+	case "skipped", "canceled":
+		icon = ":heavy_minus_sign:"
+	}
+	text := icon + " " + e.summary()
+	if e.GistURL != "" {
+		text += " <" + e.GistURL + "|results>"
+	}
+	b, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return sendJSON("POST", u, b, nil)
+}
+
+// postMatrix posts the finished job to a Matrix room.
+//
+// https://spec.matrix.org/latest/client-server-api/#put_matrixclientv3roomsroomidsendeventtypetxnid
+func postMatrix(n *gohci.Notification, e *jobEvent) error {
+	text := e.summary()
+	if f := e.failedChecks(); len(f) != 0 {
+		text += "\nFailed: " + strings.Join(f, ", ")
+	}
+	if e.GistURL != "" {
+		text += "\n" + e.GistURL
+	}
+	b, err := json.Marshal(map[string]string{"msgtype": "m.notice", "body": text})
+	if err != nil {
+		return err
+	}
+	// The homeserver ignores a transaction ID it already saw for this token.
+	// It is never retried, so the timestamp keeps the job's messages distinct,
+	// e.g. when the job ID restarts from 1 with a new history.db.
+	txn := fmt.Sprintf("gohci-%s-%d-%d", e.Worker, e.ID, time.Now().UnixNano())
+	u := strings.TrimSuffix(n.MatrixHomeserver, "/") + "/_matrix/client/v3/rooms/" + url.PathEscape(n.MatrixRoom) + "/send/m.room.message/" + url.PathEscape(txn)
+	return sendJSON("PUT", u, b, map[string]string{"Authorization": "Bearer " + n.MatrixToken})
+}
+
+// postWebhook posts the JSON summary of the finished job.
+func postWebhook(n *gohci.Notification, e *jobEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	h := map[string]string{"X-Gohci-Event": e.Event}
+	if n.WebhookSecret != "" {
+		h["X-Gohci-Signature-256"] = signBody(n.WebhookSecret, b)
+	}
+	return sendJSON("POST", n.Webhook, b, h)
+}
+
+// muMQTT serializes the publications, so the events of concurrent jobs
+// don't race on the broker and a job's retained events stay in order.
+var muMQTT sync.Mutex
+
+// publishMQTT publishes the JSON summary of the job to the MQTT broker.
+func publishMQTT(n *gohci.Notification, e *jobEvent) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	prefix := n.MQTTTopic
+	if prefix == "" {
+		prefix = "gohci"
+	}
+	topic := strings.Join([]string{prefix, e.Worker, e.Org, e.Repo}, "/")
+	muMQTT.Lock()
+	defer muMQTT.Unlock()
+	return mqttPublish(n.MQTTBroker, n.MQTTUser, n.MQTTPassword, mqttClientID(e), topic, b, true)
+}
+
+// mqttClientID returns a client ID unique to the event.
+//
+// MQTT 3.1.1 brokers only have to accept up to 23 bytes, so the worker's name
+// is hashed.
+func mqttClientID(e *jobEvent) string {
+	h := sha256.Sum256([]byte(fmt.Sprintf("%s-%d-%s", e.Worker, e.ID, e.Event)))
+	return "gohci-" + hex.EncodeToString(h[:8])
+}
+
+// signBody returns the HMAC-SHA256 signature of b as "sha256=<hex>".
+func signBody(secret string, b []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(b)
+	return "sha256=" + hex.EncodeToString(m.Sum(nil))
+}
+
+// sendJSON sends a JSON body with the optional headers.
+func sendJSON(method, u string, b []byte, headers map[string]string) error {
+	req, err := http.NewRequest(method, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
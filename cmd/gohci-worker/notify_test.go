@@ -0,0 +1,62 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchRepos(t *testing.T) {
+	data := []struct {
+		repos     []string
+		org, repo string
+		want      bool
+	}{
+		{nil, "periph", "gohci", true},
+		{[]string{"periph"}, "Periph", "gohci", true},
+		{[]string{"periph/gohci"}, "periph", "gohci", true},
+		{[]string{"periph/host"}, "periph", "gohci", false},
+		{[]string{"maruel"}, "periph", "gohci", false},
+	}
+	for i, l := range data {
+		if got := matchRepos(l.repos, l.org, l.repo); got != l.want {
+			t.Fatalf("#%d: matchRepos(%v, %q, %q) = %t", i, l.repos, l.org, l.repo, got)
+		}
+	}
+}
+
+func TestSignBody(t *testing.T) {
+	// Same as GitHub's example in
+	// https://docs.github.com/en/webhooks/using-webhooks/validating-webhook-deliveries
+	got := signBody("It's a Secret to Everybody", []byte("Hello, World!"))
+	if want := "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"; got != want {
+		t.Fatalf("%q != %q", got, want)
+	}
+}
+
+func TestJobEventSummary(t *testing.T) {
+	e := &jobEvent{Event: "finished", Worker: "pi", jobRecord: jobRecord{Org: "periph", Repo: "gohci", Commit: "0123456789abcdef", PullID: 12, State: "success", Duration: 62 * time.Second}}
+	if got, want := e.summary(), "periph/gohci@0123456789ab (PR #12) success in 1m2s on pi"; got != want {
+		t.Fatalf("%q != %q", got, want)
+	}
+	e.Event = "started"
+	if got, want := e.summary(), "periph/gohci@0123456789ab (PR #12) started on pi"; got != want {
+		t.Fatalf("%q != %q", got, want)
+	}
+}
+
+func TestMQTTClientID(t *testing.T) {
+	e := &jobEvent{Event: "finished", Worker: "a-worker-with-a-very-long-hostname", jobRecord: jobRecord{ID: 1234567890}}
+	id := mqttClientID(e)
+	if len(id) > 23 || !strings.HasPrefix(id, "gohci-") || mqttClientID(e) != id {
+		t.Fatal(id)
+	}
+	e.Event = "started"
+	if mqttClientID(e) == id {
+		t.Fatal("must be unique per event")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+const (
+	// partialInterval is how often the output of the running command is
+	// uploaded.
+	partialInterval = 30 * time.Second
+	// partialMaxSize is the maximum size of the partial output uploaded. Only
+	// the end is kept.
+	partialMaxSize = 512 * 1024
+	// gistRunningFile is the gist file holding the output of the running
+	// command. It sorts before the other files.
+	gistRunningFile = "_running"
+)
+
+// lockedBuffer is a bytes.Buffer that can be read while the command writes
+// to it.
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+// bytes returns a copy of the content.
+func (l *lockedBuffer) bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]byte(nil), l.b.Bytes()...)
+}
+
+// partialOutput returns the output so far of the command running, or "".
+func (j *jobRequest) partialOutput() string {
+	b := j.current.Load()
+	if b == nil {
+		return ""
+	}
+	out := b.bytes()
+	if len(out) > partialMaxSize {
+		out = append([]byte("<truncated>\n"), out[len(out)-partialMaxSize:]...)
+	}
+	return string(normalizeUTF8(out))
+}
+
+// uploadPartial replaces the content of the gist's running file, or deletes
+// it when content is empty.
+func (w *workerQueue) uploadPartial(j *jobRequest, o *gistOutput, content string) {
+	if j.local {
+		return
+	}
+	if w.blobs != nil {
+		w.blobPartial(j, o, content)
+		return
+	}
+	var f interface{}
+	if content != "" {
+		f = github.GistFile{Content: &content}
+	}
+	// The file can only be deleted with null, which github.GistFile can't
+	// express.
+	b := map[string]interface{}{"files": map[string]interface{}{gistRunningFile: f}}
+	req, err := w.gists.NewRequest("PATCH", "gists/"+o.primary.GetID(), b)
+	if err == nil {
+		_, err = w.gists.Do(w.ctx, req, nil)
+	}
+	if err != nil {
+		j.log.Error("failed to update the partial output", "err", err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestPartialOutput(t *testing.T) {
+	j := &jobRequest{}
+	if got := j.partialOutput(); got != "" {
+		t.Fatal(got)
+	}
+	b := &lockedBuffer{}
+	j.current.Store(b)
+	_, _ = b.Write([]byte("hello\n"))
+	if got := j.partialOutput(); got != "hello\n" {
+		t.Fatal(got)
+	}
+	_, _ = b.Write([]byte(strings.Repeat("a", partialMaxSize)))
+	if got := j.partialOutput(); !strings.HasPrefix(got, "<truncated>\naaa") || len(got) != partialMaxSize+len("<truncated>\n") {
+		t.Fatal(len(got))
+	}
+}
+
+func TestUploadPartial(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/gists/g1" {
+			http.NotFound(w, r)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, strings.TrimSpace(string(b)))
+		_, _ = io.WriteString(w, "{}")
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{ctx: context.Background(), client: client, gists: client}
+	j := &jobRequest{log: slog.Default()}
+	o := newGistOutput(&github.Gist{ID: github.String("g1")})
+	w.uploadPartial(j, o, "out")
+	w.uploadPartial(j, o, "")
+	want := []string{`{"files":{"_running":{"content":"out"}}}`, `{"files":{"_running":null}}`}
+	if len(bodies) != 2 || bodies[0] != want[0] || bodies[1] != want[1] {
+		t.Fatalf("%q", bodies)
+	}
+}
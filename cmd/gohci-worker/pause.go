@@ -0,0 +1,43 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// servePause handles the authenticated POST /api/v1/pause, /api/v1/resume and
+// /api/v1/drain.
+//
+// While paused, the worker refuses new jobs but the ones already enqueued
+// complete. Draining pauses the worker and then exits with code 0 once the
+// queue is empty, so the worker can be upgraded without interrupting a job.
+func (s *server) servePause(w http.ResponseWriter, r *http.Request, action string) {
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	s.w.setPaused(action != "resume")
+	logServer.Info("worker "+action, "paused", s.w.isPaused())
+	if action == "drain" {
+		s.drainOnce.Do(func() { close(s.drain) })
+	}
+	writeJSON(w, struct {
+		Paused bool `json:"paused"`
+	}{s.w.isPaused()})
+}
+
+// pauseWorker asks the worker running locally to pause or resume.
+func pauseWorker(port int, secret, action string) error {
+	if _, err := adminRequest(port, secret, "POST", action); err != nil {
+		return fmt.Errorf("%s failed: %w", action, err)
+	}
+	return nil
+}
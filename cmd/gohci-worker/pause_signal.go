@@ -0,0 +1,24 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// handlePauseSignal toggles the pause mode of the worker on each SIGUSR1.
+func handlePauseSignal(w worker) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGUSR1)
+	for range c {
+		w.setPaused(!w.isPaused())
+		logMain.Info("SIGUSR1", "paused", w.isPaused())
+	}
+}
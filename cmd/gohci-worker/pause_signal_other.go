@@ -0,0 +1,13 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build windows || plan9
+// +build windows plan9
+
+package main
+
+// handlePauseSignal is not supported, there's no SIGUSR1. Use -pause and
+// -resume instead.
+func handlePauseSignal(w worker) {
+}
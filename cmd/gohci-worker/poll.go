@@ -0,0 +1,150 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// head is a commit to test found while polling.
+type head struct {
+	key    string // Identifies the branch or PR, e.g. "branch/main" or "pr/12".
+	sha    string
+	ref    string // Branch, for the default branch.
+	pullID int    // PR number, for PRs.
+	user   string // PR author.
+	draft  bool
+}
+
+// heads implements worker.
+func (w *workerQueue) heads(org, repo string) ([]head, error) {
+	var out []head
+	if b := w.defaultBranch(org, repo); b != "" {
+		br, _, err := w.client.Repositories.GetBranch(w.ctx, org, repo, b)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, head{key: "branch/" + b, sha: br.GetCommit().GetSHA(), ref: "refs/heads/" + b})
+	}
+	opts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		prs, resp, err := w.client.PullRequests.List(w.ctx, org, repo, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, pr := range prs {
+			out = append(out, head{
+				key:    fmt.Sprintf("pr/%d", pr.GetNumber()),
+				sha:    pr.GetHead().GetSHA(),
+				pullID: pr.GetNumber(),
+				user:   pr.GetUser().GetLogin(),
+				draft:  pr.GetDraft(),
+			})
+		}
+		if resp.NextPage == 0 {
+			return out, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// hasStatus implements worker.
+func (w *workerQueue) hasStatus(org, repo, sha string) (bool, error) {
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		statuses, resp, err := w.client.Repositories.ListStatuses(w.ctx, org, repo, sha, opts)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range statuses {
+			if s.GetContext() == w.name {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// runPoller polls GitHub for new commits instead of receiving webhooks, for
+// workers that can't accept inbound connections.
+//
+// The first poll only enqueues the heads that have no status from this worker
+// yet, so a restart tests the commits pushed while it was down without
+// triggering a storm of jobs.
+func runPoller(c *gohci.WorkerConfig, w worker, fileName string) error {
+	thisFile, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	logServer.Info("polling", "repos", strings.Join(c.PollRepos, ","), "interval", interval)
+	go func() {
+		seen := map[string]string{}
+		for first := true; ; first = false {
+			for _, r := range c.PollRepos {
+				pollRepo(c, w, r, seen, first)
+			}
+			time.Sleep(interval)
+		}
+	}()
+	_ = SetConsoleTitle("gohci - polling")
+	err = waitForChange(thisFile, fileName)
+	// Ensures no task is running.
+	w.wait()
+	return err
+}
+
+// pollRepo enqueues the heads of the repository "org/repo" that changed
+// since the last poll. On the first poll, it enqueues the heads not yet
+// tested.
+func pollRepo(c *gohci.WorkerConfig, w worker, r string, seen map[string]string, first bool) {
+	parts := strings.SplitN(r, "/", 2)
+	org, repo := parts[0], parts[1]
+	heads, err := w.heads(org, repo)
+	if err != nil {
+		logServer.Error("failed to poll", "repo", r, "err", err)
+		return
+	}
+	for _, h := range heads {
+		k := r + "/" + h.key
+		if seen[k] == h.sha {
+			continue
+		}
+		seen[k] = h.sha
+		if first {
+			if ok, err := w.hasStatus(org, repo, h.sha); err != nil {
+				logServer.Error("failed to get statuses", "repo", r, "commit", h.sha, "err", err)
+				continue
+			} else if ok {
+				continue
+			}
+		}
+		spec := jobSpec{org: org, repo: repo, commitHash: h.sha, ref: h.ref, pullID: h.pullID, draft: h.draft, event: "poll"}
+		// There's no way to know if the repository is private without another
+		// call, so use the same ssh setting as the other jobs of the worker.
+		spec.useSSH = c.PollUseSSH
+		if h.pullID != 0 && !isTrustedUser(c, w, org, repo, h.user, c.PollSuperUsers) {
+			if len(c.ForkChecks) == 0 {
+				logServer.Info("ignoring PR from not super user", "repo", r, "pr", h.pullID, "user", h.user)
+				continue
+			}
+			spec.restricted = true
+		}
+		logServer.Info("new head", "repo", r, "key", h.key, "commit", h.sha)
+		w.enqueueCheck(spec)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// pollWorker serves fixed heads and statuses.
+type pollWorker struct {
+	specWorker
+	h      []head
+	tested map[string]bool
+	team   map[string]bool
+}
+
+func (w *pollWorker) heads(org, repo string) ([]head, error) {
+	return w.h, nil
+}
+
+func (w *pollWorker) hasStatus(org, repo, sha string) (bool, error) {
+	return w.tested[sha], nil
+}
+
+func (w *pollWorker) inTeam(org, team, user string) bool {
+	return w.team[org+"/"+team+"/"+user]
+}
+
+func (w *pollWorker) canWrite(org, repo, user string) bool {
+	return false
+}
+
+func TestPollRepo(t *testing.T) {
+	w := &pollWorker{
+		h: []head{
+			{key: "branch/main", sha: "a", ref: "refs/heads/main"},
+			{key: "pr/1", sha: "b", pullID: 1, user: "member"},
+			{key: "pr/2", sha: "c", pullID: 2, user: "stranger"},
+		},
+		tested: map[string]bool{"a": true},
+		team:   map[string]bool{"o/t/member": true},
+	}
+	c := &gohci.WorkerConfig{PollSuperUsers: []string{"o/t"}}
+	seen := map[string]string{}
+	// The first poll enqueues the heads without a status from this worker, and
+	// the team member's PR is trusted.
+	pollRepo(c, w, "o/r", seen, true)
+	if len(w.specs) != 1 || w.specs[0].commitHash != "b" || w.specs[0].restricted {
+		t.Fatalf("%+v", w.specs)
+	}
+	// The following polls only enqueue the heads that changed.
+	w.specs = nil
+	w.h[0].sha = "d"
+	pollRepo(c, w, "o/r", seen, false)
+	if len(w.specs) != 1 || w.specs[0].commitHash != "d" {
+		t.Fatalf("%+v", w.specs)
+	}
+}
+
+func TestHeads(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/o/r/branches/main":
+			_, _ = io.WriteString(w, `{"name":"main","commit":{"sha":"a"}}`)
+		case "/repos/o/r/pulls":
+			if r.URL.Query().Get("page") == "2" {
+				_, _ = io.WriteString(w, `[{"number":2,"head":{"sha":"c"},"user":{"login":"u"}}]`)
+				return
+			}
+			w.Header().Set("Link", `<`+ts.URL+`/repos/o/r/pulls?page=2>; rel="next"`)
+			_, _ = io.WriteString(w, `[{"number":1,"head":{"sha":"b"},"user":{"login":"u"}}]`)
+		case "/repos/o/r/commits/a/statuses":
+			_, _ = io.WriteString(w, `[{"context":"other"},{"context":"gohci"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{name: "gohci", ctx: context.Background(), client: client, branches: map[string]string{"o/r": "main"}}
+	h, err := w.heads("o", "r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h) != 3 || h[0].sha != "a" || h[1].sha != "b" || h[2].sha != "c" {
+		t.Fatalf("%+v", h)
+	}
+	if ok, err := w.hasStatus("o", "r", "a"); !ok || err != nil {
+		t.Fatal(ok, err)
+	}
+}
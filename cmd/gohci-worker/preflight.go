@@ -0,0 +1,108 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"periph.io/x/gohci"
+)
+
+// sysUSBDevices is where Linux lists the USB devices.
+const sysUSBDevices = "/sys/bus/usb/devices"
+
+// preflight returns the devices declared by the project that are missing.
+func preflight(d *gohci.Devices) []string {
+	var missing []string
+	for _, p := range d.Paths {
+		if _, err := os.Stat(p); err != nil {
+			missing = append(missing, p)
+		}
+	}
+	if len(d.USB) != 0 {
+		ids := usbIDs(sysUSBDevices)
+		for _, id := range d.USB {
+			if !ids[strings.ToLower(id)] {
+				missing = append(missing, "usb "+id)
+			}
+		}
+	}
+	for _, a := range d.I2C {
+		if !i2cPresent(a) {
+			missing = append(missing, "i2c "+a)
+		}
+	}
+	return missing
+}
+
+// usbIDs returns the "vid:pid" of the USB devices listed in root.
+func usbIDs(root string) map[string]bool {
+	out := map[string]bool{}
+	dirs, _ := filepath.Glob(filepath.Join(root, "*"))
+	for _, d := range dirs {
+		/* #nosec G304 */
+		v, err1 := os.ReadFile(filepath.Join(d, "idVendor"))
+		/* #nosec G304 */
+		p, err2 := os.ReadFile(filepath.Join(d, "idProduct"))
+		if err1 == nil && err2 == nil {
+			out[strings.ToLower(strings.TrimSpace(string(v))+":"+strings.TrimSpace(string(p)))] = true
+		}
+	}
+	return out
+}
+
+// i2cPresent returns true if a device acknowledges at "bus:addr", e.g.
+// "1:0x48", per i2cdetect.
+func i2cPresent(a string) bool {
+	bus, addr, err := parseI2C(a)
+	if err != nil {
+		return false
+	}
+	h := fmt.Sprintf("0x%02x", addr)
+	/* #nosec G204 */
+	out, err := exec.Command("i2cdetect", "-y", strconv.Itoa(bus), h, h).Output()
+	return err == nil && i2cDetected(string(out), addr)
+}
+
+// parseI2C parses "bus:addr".
+func parseI2C(a string) (int, int, error) {
+	b, ad, ok := strings.Cut(a, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid i2c device %q; use \"bus:addr\"", a)
+	}
+	bus, err := strconv.Atoi(b)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid i2c bus %q", b)
+	}
+	addr, err := strconv.ParseInt(ad, 0, 8)
+	if err != nil || addr < 0 {
+		return 0, 0, fmt.Errorf("invalid i2c address %q", ad)
+	}
+	return bus, int(addr), nil
+}
+
+// i2cDetected returns true if the output of i2cdetect scanning only addr
+// shows a device, either responding or in use by a kernel driver ("UU").
+func i2cDetected(out string, addr int) bool {
+	h := fmt.Sprintf("%02x", addr)
+	lines := strings.Split(out, "\n")
+	if len(lines) == 0 {
+		return false
+	}
+	// Skip the column header.
+	for _, l := range lines[1:] {
+		for _, f := range strings.Fields(l) {
+			if f == h || f == "UU" {
+				return true
+			}
+		}
+	}
+	return false
+}
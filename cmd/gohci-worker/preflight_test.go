@@ -0,0 +1,83 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestUSBIDs(t *testing.T) {
+	root := t.TempDir()
+	for name, ids := range map[string][2]string{"1-1": {"2E8A", "0003"}, "1-1.2": {"0403", "6001"}} {
+		d := filepath.Join(root, name)
+		if err := os.Mkdir(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "idVendor"), []byte(ids[0]+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "idProduct"), []byte(ids[1]+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// An interface, without IDs.
+	if err := os.Mkdir(filepath.Join(root, "1-1:1.0"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"2e8a:0003": true, "0403:6001": true}
+	if got := usbIDs(root); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%v", got)
+	}
+}
+
+func TestParseI2C(t *testing.T) {
+	if bus, addr, err := parseI2C("1:0x48"); bus != 1 || addr != 0x48 || err != nil {
+		t.Fatal(bus, addr, err)
+	}
+	for _, a := range []string{"", "1", "a:0x48", "1:0x148", "1:x"} {
+		if _, _, err := parseI2C(a); err == nil {
+			t.Fatalf("%q", a)
+		}
+	}
+}
+
+func TestI2CDetected(t *testing.T) {
+	const header = "     0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f\n"
+	const present = header + "40:                         48                      \n"
+	const absent = header + "40:                         --                      \n"
+	const used = header + "40:                         UU                      \n"
+	if !i2cDetected(present, 0x48) {
+		t.Fatal("present")
+	}
+	if i2cDetected(absent, 0x48) {
+		t.Fatal("absent")
+	}
+	if !i2cDetected(used, 0x48) {
+		t.Fatal("used")
+	}
+	// The row label doesn't count.
+	if i2cDetected(header+"40:\n", 0x40) {
+		t.Fatal("label")
+	}
+}
+
+func TestPreflightPaths(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "ttyACM0")
+	want := []string{p}
+	if got := preflight(&gohci.Devices{Paths: []string{p}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%v", got)
+	}
+	if err := os.WriteFile(p, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := preflight(&gohci.Devices{Paths: []string{p}}); len(got) != 0 {
+		t.Fatalf("%v", got)
+	}
+}
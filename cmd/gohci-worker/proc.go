@@ -0,0 +1,84 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group, so
+// killProcessGroup also kills its children, like the test binaries started by
+// "go test".
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// startProcessGroup starts the command.
+func startProcessGroup(c *exec.Cmd) error {
+	return c.Start()
+}
+
+// killStaleGroup kills the process group if it still exists. Returns true if
+// it did.
+func killStaleGroup(pgid int) bool {
+	return syscall.Kill(-pgid, syscall.SIGKILL) == nil
+}
+
+// bootID returns an identifier of the current boot, or "" if unknown.
+func bootID() string {
+	if runtime.GOOS == "linux" {
+		b, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	// macOS and the BSDs.
+	b, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// processStartTime returns when the process started, in an OS specific
+// format, or "" if it doesn't exist.
+func processStartTime(pid int) string {
+	if runtime.GOOS == "linux" {
+		/* #nosec G304 */
+		b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil {
+			return ""
+		}
+		// The command name in parenthesis may contain spaces. The start time is
+		// the 22nd field.
+		s := string(b)
+		f := strings.Fields(s[strings.LastIndexByte(s, ')')+1:])
+		if len(f) < 20 {
+			return ""
+		}
+		return f[19]
+	}
+	/* #nosec G204 */
+	b, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// killProcessGroup kills the process group of a command started with
+// setProcessGroup.
+func killProcessGroup(c *exec.Cmd) error {
+	return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
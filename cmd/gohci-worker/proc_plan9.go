@@ -0,0 +1,36 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import "os/exec"
+
+// setProcessGroup is a no-op on this OS.
+func setProcessGroup(c *exec.Cmd) {
+}
+
+// startProcessGroup starts the command.
+func startProcessGroup(c *exec.Cmd) error {
+	return c.Start()
+}
+
+// killStaleGroup is not supported on this OS.
+func killStaleGroup(pgid int) bool {
+	return false
+}
+
+// bootID is not supported on this OS.
+func bootID() string {
+	return ""
+}
+
+// processStartTime is not supported on this OS.
+func processStartTime(pid int) string {
+	return ""
+}
+
+// killProcessGroup kills the process. Its children are not killed on this OS.
+func killProcessGroup(c *exec.Cmd) error {
+	return c.Process.Kill()
+}
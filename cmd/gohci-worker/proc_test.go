@@ -0,0 +1,108 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunKillsOrphans(t *testing.T) {
+	j := newJobRequest(jobSpec{org: "periph", repo: "gohci"}, t.TempDir())
+	if err := os.MkdirAll(j.gopath, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// Use a script since run() expands the variables of the command line.
+	pidFile := filepath.Join(j.gopath, "pid")
+	script := filepath.Join(j.gopath, "daemon.sh")
+	if err := os.WriteFile(script, []byte("sleep 60 >/dev/null 2>&1 &\necho $! > "+pidFile+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out, ok := j.run("", nil, []string{"sh", script}, false); !ok {
+		t.Fatal(out)
+	}
+	b, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The process may linger as a zombie until reaped by init.
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		s, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+		if err != nil {
+			return
+		}
+		if f := strings.Fields(string(s)); len(f) > 2 && f[2] == "Z" {
+			return
+		}
+	}
+	t.Fatalf("process %d is still running", pid)
+}
+
+func TestKillStale(t *testing.T) {
+	c := exec.Command("sleep", "60")
+	setProcessGroup(c)
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "processes.txt")
+	// Simulates a crashed worker.
+	newProcRegistry(path).add(c.Process.Pid)
+	p := newProcRegistry(path)
+	if got := p.killStale(); len(got) != 1 || got[0] != c.Process.Pid {
+		t.Fatal(got)
+	}
+	if err := c.Wait(); err == nil {
+		t.Fatal("expected the process to be killed")
+	}
+}
+
+func TestKillStaleReused(t *testing.T) {
+	c := exec.Command("sleep", "60")
+	setProcessGroup(c)
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = killProcessGroup(c)
+		_ = c.Wait()
+	}()
+	pid := c.Process.Pid
+	start := processStartTime(pid)
+	if start == "" {
+		t.Fatal("unknown start time")
+	}
+	path := filepath.Join(t.TempDir(), "processes.txt")
+	data := []string{
+		// The PID was reused by another process.
+		"boot " + bootID() + "\n" + strconv.Itoa(pid) + " 1\n",
+		// Recorded before a reboot.
+		"boot other\n" + strconv.Itoa(pid) + " " + start + "\n",
+		// Old format without boot ID.
+		strconv.Itoa(pid) + "\n",
+	}
+	for i, d := range data {
+		if err := os.WriteFile(path, []byte(d), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if got := newProcRegistry(path).killStale(); len(got) != 0 {
+			t.Fatal(i, got)
+		}
+		if processStartTime(pid) != start {
+			t.Fatal(i, "process was killed")
+		}
+	}
+}
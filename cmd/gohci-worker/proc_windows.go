@@ -0,0 +1,90 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os/exec"
+	"sync"
+	"syscall"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+
+	muJobObjects sync.Mutex
+	jobObjects   = map[*exec.Cmd]syscall.Handle{}
+)
+
+// processSetQuota is PROCESS_SET_QUOTA, needed by AssignProcessToJobObject.
+const processSetQuota = 0x0100
+
+// setProcessGroup is a no-op; the Job Object is created by
+// startProcessGroup.
+func setProcessGroup(c *exec.Cmd) {
+}
+
+// startProcessGroup starts the command in a Job Object, so killProcessGroup
+// also kills its children, even after the process exited.
+//
+// The children started before the process is assigned to the Job Object are
+// not tracked.
+func startProcessGroup(c *exec.Cmd) error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	h, _, _ := procCreateJobObjectW.Call(0, 0)
+	if h == 0 {
+		return nil
+	}
+	p, err := syscall.OpenProcess(syscall.PROCESS_TERMINATE|processSetQuota, false, uint32(c.Process.Pid))
+	if err != nil {
+		_ = syscall.CloseHandle(syscall.Handle(h))
+		return nil
+	}
+	defer syscall.CloseHandle(p)
+	if r, _, _ := procAssignProcessToJobObject.Call(h, uintptr(p)); r == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(h))
+		return nil
+	}
+	muJobObjects.Lock()
+	jobObjects[c] = syscall.Handle(h)
+	muJobObjects.Unlock()
+	return nil
+}
+
+// killStaleGroup is not supported on this OS; the Job Objects are not kept
+// across restarts.
+func killStaleGroup(pgid int) bool {
+	return false
+}
+
+// bootID is not supported on this OS.
+func bootID() string {
+	return ""
+}
+
+// processStartTime is not supported on this OS.
+func processStartTime(pid int) string {
+	return ""
+}
+
+// killProcessGroup kills all the processes in the command's Job Object.
+func killProcessGroup(c *exec.Cmd) error {
+	muJobObjects.Lock()
+	h, ok := jobObjects[c]
+	delete(jobObjects, c)
+	muJobObjects.Unlock()
+	if !ok {
+		return c.Process.Kill()
+	}
+	defer syscall.CloseHandle(h)
+	if r, _, err := procTerminateJobObject.Call(uintptr(h), 1); r == 0 {
+		return err
+	}
+	return nil
+}
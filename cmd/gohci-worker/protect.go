@@ -0,0 +1,108 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// protectBranches marks the worker's status as a required check on the
+// default branch of the repositories in AllowedRepos and PollRepos.
+//
+// Like registerWebhooks, it uses an admin token read from $GOHCI_ADMIN_TOKEN or
+// from stdin instead of the worker's token.
+func protectBranches(c *gohci.WorkerConfig) error {
+	var repos []string
+	seen := map[string]bool{}
+	for _, r := range append(append([]string{}, c.AllowedRepos...), c.PollRepos...) {
+		if !seen[r] {
+			seen[r] = true
+			repos = append(repos, r)
+		}
+	}
+	if len(repos) == 0 {
+		return errors.New("no repository in allowedrepos or pollrepos")
+	}
+	token, err := readAdminToken()
+	if err != nil {
+		return err
+	}
+	client := github.NewClient(&http.Client{Transport: &tokenRotator{base: http.DefaultTransport, tokens: []string{token}}})
+	failed := 0
+	for _, r := range repos {
+		parts := strings.SplitN(r, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			fmt.Printf("%s: invalid repository; use \"org/repo\"\n", r)
+			failed++
+			continue
+		}
+		msg, err := protectBranch(context.Background(), client, parts[0], parts[1], c.Name)
+		if err != nil {
+			fmt.Printf("%s: %v\n", r, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: %s\n", r, msg)
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed to protect %d repositories", failed)
+	}
+	return nil
+}
+
+// protectBranch adds the status name as a required check on the repository's
+// default branch. Returns what was done.
+//
+// It only adds the context to the existing required status checks and never
+// overrides the other protection settings. This requires admin access to the
+// repository.
+func protectBranch(ctx context.Context, client *github.Client, org, repo, name string) (string, error) {
+	r, _, err := client.Repositories.Get(ctx, org, repo)
+	if err != nil {
+		return "", err
+	}
+	b := r.GetDefaultBranch()
+	if b == "" {
+		return "", errors.New("failed to get the default branch")
+	}
+	chks, resp, err := client.Repositories.GetRequiredStatusChecks(ctx, org, repo, b)
+	if err == nil {
+		for _, c := range chks.Contexts {
+			if c == name {
+				return fmt.Sprintf("%q is already required on %s", name, b), nil
+			}
+		}
+		_, _, err = client.Repositories.UpdateRequiredStatusChecks(ctx, org, repo, b, &github.RequiredStatusChecksRequest{
+			Contexts: append(chks.Contexts, name),
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%q added to the required checks of %s", name, b), nil
+	}
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return "", err
+	}
+	// Either the branch is not protected or it doesn't require status checks.
+	if _, resp, err = client.Repositories.GetBranchProtection(ctx, org, repo, b); err == nil {
+		return "", fmt.Errorf("%s is protected without required status checks; enable them and add %q in the repository settings", b, name)
+	} else if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return "", err
+	}
+	_, _, err = client.Repositories.UpdateBranchProtection(ctx, org, repo, b, &github.ProtectionRequest{
+		RequiredStatusChecks: &github.RequiredStatusChecks{Contexts: []string{name}},
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s protected, requiring %q", b, name), nil
+}
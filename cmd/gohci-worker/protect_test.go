@@ -0,0 +1,54 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestProtectBranch(t *testing.T) {
+	var writes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/repos/o/checks"):
+			_, _ = io.WriteString(w, `{"default_branch":"main"}`)
+		case r.Method == "GET" && r.URL.Path == "/repos/o/checks/branches/main/protection/required_status_checks":
+			_, _ = io.WriteString(w, `{"strict":true,"contexts":["lint"]}`)
+		case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/repos/o/bare"):
+			_, _ = io.WriteString(w, `{"default_branch":"master"}`)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/repos/o/bare/branches/master/protection"):
+			http.NotFound(w, r)
+		default:
+			writes = append(writes, r.Method+" "+r.URL.Path+" "+strings.TrimSpace(string(b)))
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	ctx := context.Background()
+	if msg, err := protectBranch(ctx, client, "o", "checks", "w"); err != nil || msg != `"w" added to the required checks of main` {
+		t.Fatal(msg, err)
+	}
+	if msg, err := protectBranch(ctx, client, "o", "bare", "w"); err != nil || msg != `master protected, requiring "w"` {
+		t.Fatal(msg, err)
+	}
+	want := []string{
+		`PATCH /repos/o/checks/branches/main/protection/required_status_checks {"contexts":["lint","w"]}`,
+		`PUT /repos/o/bare/branches/master/protection {"required_status_checks":{"strict":false,"contexts":["w"]},"required_pull_request_reviews":null,"enforce_admins":false,"restrictions":null}`,
+	}
+	if strings.Join(writes, "\n") != strings.Join(want, "\n") {
+		t.Fatal(strings.Join(writes, "\n"))
+	}
+}
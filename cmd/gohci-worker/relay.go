@@ -0,0 +1,129 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// relayEvent is a webhook delivery as forwarded by a smee.io compatible relay.
+//
+// The headers are lowercased and the query is decoded as an object.
+type relayEvent struct {
+	Event        string            `json:"x-github-event"`
+	Delivery     string            `json:"x-github-delivery"`
+	Signature    string            `json:"x-hub-signature"`
+	Signature256 string            `json:"x-hub-signature-256"`
+	Query        map[string]string `json:"query"`
+	// Body is kept verbatim since the signature is computed on it.
+	Body json.RawMessage `json:"body"`
+}
+
+// relayLoop receives the webhook deliveries from the relay until the process
+// exits, reconnecting as needed.
+func (s *server) relayLoop() {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := s.relay()
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		logServer.Warn("relay disconnected", "url", s.c.RelayURL, "err", err, "retry", backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > 5*time.Minute {
+			backoff = 5 * time.Minute
+		}
+	}
+}
+
+// relay connects to the relay and handles the Server-Sent Events stream.
+func (s *server) relay() error {
+	req, err := http.NewRequest("GET", s.c.RelayURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	logServer.Info("relay connected", "url", s.c.RelayURL)
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 32*1024*1024)
+	var data []string
+	for sc.Scan() {
+		l := sc.Text()
+		if l == "" {
+			if len(data) != 0 {
+				s.handleRelay([]byte(strings.Join(data, "\n")))
+				data = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(l, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(l, "data:"), " "))
+		}
+	}
+	if err = sc.Err(); err == nil {
+		err = errors.New("stream closed")
+	}
+	return err
+}
+
+// handleRelay handles one event received from the relay.
+func (s *server) handleRelay(data []byte) {
+	var e relayEvent
+	if err := json.Unmarshal(data, &e); err != nil || e.Event == "" {
+		// "ready" and "ping" events.
+		return
+	}
+	if err := s.validateRelay(&e); err != nil {
+		logServer.Warn("invalid relayed delivery", "guid", e.Delivery, "err", err)
+		s.sigs.record(false)
+		return
+	}
+	values := url.Values{}
+	for k, v := range e.Query {
+		values.Set(k, v)
+	}
+	altPath, superUsers, err := validateArgs(values)
+	if err != nil {
+		logServer.Warn("invalid query argument, check your webhook URL", "query", values.Encode(), "err", err)
+		return
+	}
+	s.accept(&webhookDelivery{
+		GUID:     e.Delivery,
+		Event:    e.Event,
+		Query:    values.Encode(),
+		Received: time.Now(),
+		Payload:  e.Body,
+	}, altPath, superUsers)
+}
+
+// validateRelay verifies the signature of a relayed delivery.
+func (s *server) validateRelay(e *relayEvent) error {
+	sig := e.Signature256
+	if sig == "" {
+		sig = e.Signature
+	}
+	if sig == "" {
+		return fmt.Errorf("missing signature")
+	}
+	return github.ValidateSignature(sig, e.Body, []byte(s.c.WebHookSecret))
+}
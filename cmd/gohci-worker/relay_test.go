@@ -0,0 +1,34 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestValidateRelay(t *testing.T) {
+	body := `{"zen":"Keep it logically awesome."}`
+	m := hmac.New(sha256.New, []byte("secret"))
+	m.Write([]byte(body))
+	data := `{"x-github-event":"ping","x-hub-signature-256":"sha256=` + hex.EncodeToString(m.Sum(nil)) + `","body":` + body + `,"query":{}}`
+	var e relayEvent
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{c: &gohci.WorkerConfig{WebHookSecret: "secret"}}
+	if err := s.validateRelay(&e); err != nil {
+		t.Fatal(err)
+	}
+	s.c.WebHookSecret = "other"
+	if err := s.validateRelay(&e); err == nil {
+		t.Fatal("expected failure")
+	}
+}
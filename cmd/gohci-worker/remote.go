@@ -0,0 +1,125 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"periph.io/x/gohci"
+	"periph.io/x/gohci/internal/valid"
+)
+
+// findRemote returns the plain git remote named "org/repo", if any.
+func findRemote(c *gohci.WorkerConfig, org, repo string) *gohci.GitRemote {
+	for i := range c.GitRemotes {
+		if strings.EqualFold(c.GitRemotes[i].Name, org+"/"+repo) {
+			return &c.GitRemotes[i]
+		}
+	}
+	return nil
+}
+
+// remoteSpec returns the job for a commit of a plain git remote. Its results
+// are reported locally only.
+func remoteSpec(r *gohci.GitRemote, ref, commit string) jobSpec {
+	parts := strings.SplitN(r.Name, "/", 2)
+	return jobSpec{org: parts[0], repo: parts[1], commitHash: commit, ref: ref, remoteURL: r.URL, local: true}
+}
+
+// pollRemotes polls the plain git remotes for new commits on their branches
+// until the process exits.
+//
+// Like runPoller, the first poll only records the current heads.
+func pollRemotes(c *gohci.WorkerConfig, w worker) {
+	interval := c.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	seen := map[string]string{}
+	for first := true; ; first = false {
+		for i := range c.GitRemotes {
+			pollRemote(w, &c.GitRemotes[i], seen, first)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// pollRemote enqueues the branches of the remote that changed since the last
+// poll.
+func pollRemote(w worker, r *gohci.GitRemote, seen map[string]string, first bool) {
+	/* #nosec G204 */
+	out, err := exec.Command("git", "ls-remote", r.URL).Output()
+	if err != nil {
+		logServer.Error("failed to poll", "remote", r.URL, "err", err)
+		return
+	}
+	branches := r.Branches
+	if len(branches) == 0 {
+		branches = []string{""}
+	}
+	for _, b := range branches {
+		sha := findRef(string(out), 0, b)
+		if sha == "" {
+			logServer.Warn("branch not found", "remote", r.URL, "branch", b)
+			continue
+		}
+		k := r.Name + "/" + b
+		if seen[k] == sha {
+			continue
+		}
+		seen[k] = sha
+		if first {
+			continue
+		}
+		logServer.Info("new head", "remote", r.URL, "branch", b, "commit", sha)
+		s := remoteSpec(r, b, sha)
+		s.event = "poll"
+		w.enqueueCheck(s)
+	}
+}
+
+// serveTrigger handles /api/v1/trigger, which starts a job for a commit.
+//
+// The body is {"repo": "org/repo", "ref": "main", "commit": "<sha1>"}, where
+// ref and commit are optional. The repository can be a plain git remote or a
+// GitHub repository.
+func (s *server) serveTrigger(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	var t struct {
+		Repo   string
+		Ref    string
+		Commit string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		http.Error(w, "Invalid trigger", http.StatusBadRequest)
+		return
+	}
+	parts := strings.SplitN(t.Repo, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || (t.Ref != "" && !valid.Ref(t.Ref)) || (t.Commit != "" && (len(t.Commit) != 40 || !isSubset(t.Commit, "0123456789abcdef"))) {
+		http.Error(w, "Invalid trigger", http.StatusBadRequest)
+		return
+	}
+	var spec jobSpec
+	if rem := findRemote(s.c, parts[0], parts[1]); rem != nil {
+		spec = remoteSpec(rem, t.Ref, t.Commit)
+	} else {
+		spec = jobSpec{org: parts[0], repo: parts[1], commitHash: t.Commit, ref: t.Ref, useSSH: s.c.PollUseSSH}
+	}
+	spec.event = "trigger"
+	logServer.Info("trigger", "repo", t.Repo, "ref", t.Ref, "commit", t.Commit)
+	s.w.enqueueCheck(spec)
+	writeJSON(w, struct{}{})
+}
@@ -0,0 +1,26 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestFindRemote(t *testing.T) {
+	c := &gohci.WorkerConfig{GitRemotes: []gohci.GitRemote{{Name: "lab/firmware", URL: "git@git.lab:firmware.git"}}}
+	r := findRemote(c, "Lab", "Firmware")
+	if r == nil {
+		t.Fatal("expected remote")
+	}
+	s := remoteSpec(r, "main", "")
+	if s.org != "lab" || s.repo != "firmware" || s.remoteURL != "git@git.lab:firmware.git" || s.ref != "main" || !s.local {
+		t.Fatalf("unexpected spec %#v", s)
+	}
+	if findRemote(c, "lab", "other") != nil {
+		t.Fatal("unexpected remote")
+	}
+}
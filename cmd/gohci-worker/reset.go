@@ -0,0 +1,62 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// resetCmds returns the commands to power cycle the device.
+//
+// gpioset from libgpiod holds the line for the duration then releases it.
+// uhubctl turns the port off and on again.
+func resetCmds(r *gohci.Reset) [][]string {
+	d := r.Duration
+	if d <= 0 {
+		d = time.Second
+	}
+	var out [][]string
+	if r.GPIOChip != "" {
+		out = append(out, []string{
+			"gpioset", "--mode=time",
+			"--sec=" + strconv.FormatInt(int64(d/time.Second), 10),
+			"--usec=" + strconv.FormatInt(int64(d%time.Second/time.Microsecond), 10),
+			r.GPIOChip, fmt.Sprintf("%d=%d", r.GPIOLine, r.GPIOValue),
+		})
+	}
+	if r.USBHub != "" {
+		out = append(out, []string{
+			"uhubctl", "-l", r.USBHub, "-p", strconv.Itoa(r.USBPort), "-a", "cycle",
+			"-d", strconv.FormatFloat(d.Seconds(), 'f', -1, 64),
+		})
+	}
+	return out
+}
+
+// resetDevice power cycles the device per the project's config and waits
+// for it to come back.
+func (j *jobRequest) resetDevice() (string, bool) {
+	cmds := resetCmds(j.reset)
+	if len(cmds) == 0 {
+		return "Nothing to reset; set gpiochip or usbhub\n", false
+	}
+	out := ""
+	for _, cmd := range cmds {
+		stdout, ok := j.run("", nil, cmd, false)
+		out += stdout
+		if !ok {
+			return out, false
+		}
+	}
+	if j.reset.Wait > 0 {
+		out += fmt.Sprintf("Waiting %s for the device\n", j.reset.Wait)
+		time.Sleep(j.reset.Wait)
+	}
+	return out, true
+}
@@ -0,0 +1,39 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+func TestResetCmds(t *testing.T) {
+	data := []struct {
+		r    gohci.Reset
+		want [][]string
+	}{
+		{gohci.Reset{}, nil},
+		{
+			gohci.Reset{GPIOChip: "gpiochip0", GPIOLine: 17},
+			[][]string{{"gpioset", "--mode=time", "--sec=1", "--usec=0", "gpiochip0", "17=0"}},
+		},
+		{
+			gohci.Reset{GPIOChip: "gpiochip1", GPIOLine: 4, GPIOValue: 1, Duration: 2500 * time.Millisecond},
+			[][]string{{"gpioset", "--mode=time", "--sec=2", "--usec=500000", "gpiochip1", "4=1"}},
+		},
+		{
+			gohci.Reset{USBHub: "1-1", USBPort: 2, Duration: 1500 * time.Millisecond},
+			[][]string{{"uhubctl", "-l", "1-1", "-p", "2", "-a", "cycle", "-d", "1.5"}},
+		},
+	}
+	for i, l := range data {
+		if got := resetCmds(&l.r); !reflect.DeepEqual(got, l.want) {
+			t.Fatalf("#%d: %q != %q", i, got, l.want)
+		}
+	}
+}
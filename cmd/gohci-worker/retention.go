@@ -0,0 +1,101 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// gistCleanupInterval is how often the old gists are deleted.
+const gistCleanupInterval = 24 * time.Hour
+
+// runGistCleaner deletes the old gists at startup and then once a day.
+func runGistCleaner(w worker) {
+	for {
+		w.cleanupGists()
+		time.Sleep(gistCleanupInterval)
+	}
+}
+
+// cleanupGists implements worker.
+func (w *workerQueue) cleanupGists() {
+	var all []*github.Gist
+	opts := &github.GistListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		gists, resp, err := w.gists.Gists.List(w.ctx, "", opts)
+		if err != nil {
+			logMain.Error("failed to list gists", "err", err)
+			return
+		}
+		all = append(all, gists...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	ids := selectOldGists(w.name, all, time.Now(), w.c.GistMaxAge, w.c.GistMaxPerRepo)
+	deleted := 0
+	for _, id := range ids {
+		if _, err := w.gists.Gists.Delete(w.ctx, id); err != nil {
+			logMain.Error("failed to delete gist", "id", id, "err", err)
+			continue
+		}
+		deleted++
+	}
+	logMain.Info("deleted old gists", "deleted", deleted, "gists", len(all))
+}
+
+// selectOldGists returns the IDs of the gists created by the worker that are
+// older than maxAge or beyond the maxPerRepo most recent jobs of their
+// repository. 0 means unlimited.
+//
+// The gists of the worker are recognized by their description, "<name> for
+// https://github.com/<org>/<repo>/...". The continuation gists, "... (part N),
+// see <url>", belong to the job of the gist at <url> and are deleted along
+// with it.
+func selectOldGists(name string, gists []*github.Gist, now time.Time, maxAge time.Duration, maxPerRepo int) []string {
+	re := regexp.MustCompile(`^` + regexp.QuoteMeta(name) + ` for https://github\.com/([^/]+/[^/]+)/(?:pull|commit)/`)
+	reCont := regexp.MustCompile(` \(part \d+\), see (\S+)$`)
+	sorted := append([]*github.Gist(nil), gists...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetCreatedAt().After(sorted[j].GetCreatedAt())
+	})
+	// Group the continuation gists with their job's gist.
+	byURL := map[string]*github.Gist{}
+	for _, g := range sorted {
+		if u := g.GetHTMLURL(); u != "" && re.MatchString(g.GetDescription()) && !reCont.MatchString(g.GetDescription()) {
+			byURL[u] = g
+		}
+	}
+	parts := map[*github.Gist][]string{}
+	var jobs []*github.Gist
+	for _, g := range sorted {
+		if !re.MatchString(g.GetDescription()) {
+			continue
+		}
+		if m := reCont.FindStringSubmatch(g.GetDescription()); m != nil {
+			if p := byURL[m[1]]; p != nil {
+				parts[p] = append(parts[p], g.GetID())
+				continue
+			}
+		}
+		jobs = append(jobs, g)
+	}
+	perRepo := map[string]int{}
+	var out []string
+	for _, g := range jobs {
+		repo := re.FindStringSubmatch(g.GetDescription())[1]
+		perRepo[repo]++
+		if (maxAge > 0 && now.Sub(g.GetCreatedAt()) > maxAge) || (maxPerRepo > 0 && perRepo[repo] > maxPerRepo) {
+			out = append(out, g.GetID())
+			out = append(out, parts[g]...)
+		}
+	}
+	return out
+}
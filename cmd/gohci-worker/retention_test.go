@@ -0,0 +1,56 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+func TestSelectOldGists(t *testing.T) {
+	now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	g := func(id, desc string, age time.Duration) *github.Gist {
+		c := now.Add(-age)
+		return &github.Gist{ID: github.String(id), Description: github.String(desc), CreatedAt: &c, HTMLURL: github.String("https://gist/" + id)}
+	}
+	gists := []*github.Gist{
+		g("1", "w for https://github.com/periph/gohci/commit/abc", 100*24*time.Hour),
+		g("2", "w for https://github.com/periph/gohci/pull/1 at https://github.com/periph/gohci/commit/abc", time.Hour),
+		g("3", "w for https://github.com/periph/gohci/commit/def (part 2), see https://gist/7", 2*time.Hour),
+		g("4", "w for https://github.com/periph/host/commit/abc", 3*time.Hour),
+		g("5", "other for https://github.com/periph/gohci/commit/abc", 200*24*time.Hour),
+		g("6", "my notes", 200*24*time.Hour),
+	}
+	if got := selectOldGists("w", gists, now, 0, 0); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if got, want := selectOldGists("w", gists, now, 30*24*time.Hour, 0), []string{"1"}; !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	if got, want := selectOldGists("w", gists, now, 0, 1), []string{"3", "1"}; !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+
+	// The continuation gists are counted and deleted with their job.
+	gists = []*github.Gist{
+		g("1", "w for https://github.com/periph/gohci/commit/abc", time.Hour),
+		g("2", "w for https://github.com/periph/gohci/commit/abc (part 2), see https://gist/1", time.Hour),
+		g("3", "w for https://github.com/periph/gohci/commit/abc (part 3), see https://gist/1", time.Hour),
+		g("4", "w for https://github.com/periph/gohci/commit/def", 40*24*time.Hour),
+		g("5", "w for https://github.com/periph/gohci/commit/def (part 2), see https://gist/4", 40*24*time.Hour),
+	}
+	if got := selectOldGists("w", gists, now, 0, 2); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if got, want := selectOldGists("w", gists, now, 0, 1), []string{"4", "5"}; !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+	if got, want := selectOldGists("w", gists, now, 30*24*time.Hour, 0), []string{"4", "5"}; !reflect.DeepEqual(got, want) {
+		t.Fatal(got)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckoutReuse(t *testing.T) {
+	origin, commits := newTestOrigin(t)
+	wd := t.TempDir()
+	run := func(commit string) string {
+		j := newJobRequest(jobSpec{org: "lab", repo: "fw", remoteURL: "file://" + origin, commitHash: commit, local: true}, wd)
+		j.reuse = true
+		out, ok := j.checkout()
+		if !ok {
+			t.Fatalf("checkout failed:\n%s", out)
+		}
+		return out
+	}
+	if out := run(commits[0]); strings.Contains(out, "Reusing") {
+		t.Fatalf("unexpected reuse:\n%s", out)
+	}
+	root := filepath.Join(wd, "lab_fw", "src", "remote", "lab", "fw")
+	if err := os.WriteFile(filepath.Join(root, "junk"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out := run(commits[1]); !strings.Contains(out, "Reusing") || strings.Contains(out, "failed") {
+		t.Fatalf("expected reuse:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(root, "junk")); !os.IsNotExist(err) {
+		t.Fatalf("junk not removed: %v", err)
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "file")); err != nil || string(b) != "two" {
+		t.Fatalf("%q %v", b, err)
+	}
+}
+
+// newTestOrigin returns a git repository with two commits, "one" and "two",
+// of the file "file".
+func newTestOrigin(t *testing.T) (string, []string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	origin := t.TempDir()
+	git := func(args ...string) string {
+		c := exec.Command("git", append([]string{"-C", origin, "-c", "user.name=a", "-c", "user.email=a@a"}, args...)...)
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet")
+	var commits []string
+	for _, c := range []string{"one", "two"} {
+		if err := os.WriteFile(filepath.Join(origin, "file"), []byte(c), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "file")
+		git("commit", "--quiet", "-m", c)
+		commits = append(commits, git("rev-parse", "HEAD"))
+	}
+	return origin, commits
+}
@@ -0,0 +1,163 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+)
+
+// selfTestStep is the result of one step of the self-test.
+type selfTestStep struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// serveSelfTest handles the authenticated GET /api/v1/selftest.
+//
+// It runs the steps a webhook delivery goes through and stops at the first
+// failure, to diagnose a worker that does nothing on push.
+func (s *server) serveSelfTest(w http.ResponseWriter, r *http.Request) {
+	if !s.isAuthorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		return
+	}
+	steps := []selfTestStep{s.checkSecret()}
+	if steps[0].OK {
+		steps = append(steps, s.w.selfTest()...)
+	}
+	writeJSON(w, steps)
+}
+
+// signatureStats records the signature check of the webhook deliveries, so the
+// self-test can tell whether GitHub and the worker agree on the secret.
+type signatureStats struct {
+	mu       sync.Mutex
+	last     time.Time // When the last delivery was received.
+	lastOK   bool      // Whether the last delivery had a valid signature.
+	failures int       // Deliveries with an invalid signature since the start.
+}
+
+func (s *signatureStats) record(ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = time.Now()
+	s.lastOK = ok
+	if !ok {
+		s.failures++
+	}
+}
+
+// checkSecret reports whether the last webhook delivery had a valid signature.
+func (s *server) checkSecret() selfTestStep {
+	st := selfTestStep{Name: "secret"}
+	if s.c.WebHookSecret == "" {
+		st.Detail = "webhooksecret is not set"
+		return st
+	}
+	s.sigs.mu.Lock()
+	defer s.sigs.mu.Unlock()
+	if s.sigs.last.IsZero() {
+		st.OK = true
+		st.Detail = "no delivery received yet"
+		return st
+	}
+	ago := time.Since(s.sigs.last).Round(time.Second)
+	if !s.sigs.lastOK {
+		st.Detail = fmt.Sprintf("the last delivery %s ago had an invalid signature, %d failures since the start; the webhook's secret must match webhooksecret", ago, s.sigs.failures)
+		return st
+	}
+	st.OK = true
+	st.Detail = fmt.Sprintf("the last delivery %s ago had a valid signature, %d failures since the start", ago, s.sigs.failures)
+	return st
+}
+
+// selfTest implements worker.
+func (w *workerQueue) selfTest() []selfTestStep {
+	var out []selfTestStep
+	step := func(name string, f func() (string, error)) bool {
+		st := selfTestStep{Name: name}
+		d, err := f()
+		if err != nil {
+			st.Detail = err.Error()
+		} else {
+			st.OK = true
+			st.Detail = d
+		}
+		out = append(out, st)
+		return st.OK
+	}
+	ok := step("auth", func() (string, error) {
+		u, _, err := w.client.Users.Get(w.ctx, "")
+		if err != nil {
+			return "", err
+		}
+		return u.GetLogin(), nil
+	})
+	if w.blobs != nil {
+		ok = ok && step("storage", func() (string, error) {
+			name := w.name + "-selftest/selftest"
+			if err := w.blobs.upload(w.ctx, name, "text/plain; charset=utf-8", "This object is deleted right away.\n"); err != nil {
+				return "", err
+			}
+			if err := w.blobs.remove(w.ctx, name); err != nil {
+				return "", err
+			}
+			return "created and deleted " + w.blobs.String() + "/" + name, nil
+		})
+	} else {
+		ok = ok && step("gist", func() (string, error) {
+			g, _, err := w.gists.Gists.Create(w.ctx, &github.Gist{
+				Description: github.String(w.name + " self-test"),
+				Public:      github.Bool(false),
+				Files: map[github.GistFilename]github.GistFile{
+					"selftest": {Content: github.String("This gist is deleted right away.\n")},
+				},
+			})
+			if err != nil {
+				return "", err
+			}
+			if _, err = w.gists.Gists.Delete(w.ctx, g.GetID()); err != nil {
+				return "", err
+			}
+			return "created and deleted " + g.GetID(), nil
+		})
+	}
+	if ok && w.c.SelfTestRepo != "" {
+		step("status", func() (string, error) {
+			parts := strings.SplitN(w.c.SelfTestRepo, "/", 2)
+			b := w.defaultBranch(parts[0], parts[1])
+			if b == "" {
+				return "", errors.New("failed to get the default branch of " + w.c.SelfTestRepo)
+			}
+			br, _, err := w.client.Repositories.GetBranch(w.ctx, parts[0], parts[1], b)
+			if err != nil {
+				return "", err
+			}
+			sha := br.GetCommit().GetSHA()
+			_, _, err = w.client.Repositories.CreateStatus(w.ctx, parts[0], parts[1], sha, &github.RepoStatus{
+				State:       github.String("success"),
+				Description: github.String("Self-test"),
+				Context:     github.String(w.name + " self-test"),
+			})
+			if err != nil {
+				return "", err
+			}
+			return "set on " + w.c.SelfTestRepo + "@" + sha, nil
+		})
+	}
+	return out
+}
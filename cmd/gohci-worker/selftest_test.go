@@ -0,0 +1,88 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+func TestCheckSecret(t *testing.T) {
+	s := &server{c: &gohci.WorkerConfig{}}
+	if st := s.checkSecret(); st.OK {
+		t.Fatal(st)
+	}
+	s.c.WebHookSecret = "secret"
+	if st := s.checkSecret(); !st.OK || st.Detail != "no delivery received yet" {
+		t.Fatal(st)
+	}
+	s.sigs.record(false)
+	if st := s.checkSecret(); st.OK || !strings.Contains(st.Detail, "invalid signature, 1 failures") {
+		t.Fatal(st)
+	}
+	s.sigs.record(true)
+	if st := s.checkSecret(); !st.OK || !strings.Contains(st.Detail, "valid signature, 1 failures") {
+		t.Fatal(st)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	gistOK := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /user":
+			_, _ = io.WriteString(w, `{"login":"bot"}`)
+		case "POST /gists":
+			if !gistOK {
+				http.Error(w, `{"message":"nope"}`, http.StatusForbidden)
+				return
+			}
+			_, _ = io.WriteString(w, `{"id":"g1"}`)
+		case "DELETE /gists/g1":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /repos/o/r":
+			_, _ = io.WriteString(w, `{"default_branch":"main"}`)
+		case "GET /repos/o/r/branches/main":
+			_, _ = io.WriteString(w, `{"commit":{"sha":"abc"}}`)
+		case "POST /repos/o/r/statuses/abc":
+			_, _ = io.WriteString(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{
+		name:     "w",
+		c:        &gohci.WorkerConfig{SelfTestRepo: "o/r"},
+		ctx:      context.Background(),
+		client:   client,
+		gists:    client,
+		branches: map[string]string{},
+	}
+	want := []selfTestStep{
+		{Name: "auth", OK: true, Detail: "bot"},
+		{Name: "gist", OK: true, Detail: "created and deleted g1"},
+		{Name: "status", OK: true, Detail: "set on o/r@abc"},
+	}
+	if got := w.selfTest(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("%+v", got)
+	}
+	gistOK = false
+	got := w.selfTest()
+	if len(got) != 2 || !got[0].OK || got[1].OK || got[1].Detail == "" {
+		t.Fatalf("%+v", got)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// maxSerialSize is the maximum transcript kept per serial port, so a chatty
+// device doesn't blow up the gist.
+const maxSerialSize = 1024 * 1024
+
+// startSerial captures the serial port until stop is closed.
+//
+// The transcript is sent as a gist file. It is a failure when the port can't
+// be opened, e.g. the device is not connected.
+func startSerial(p gohci.SerialPort, stop <-chan struct{}) <-chan gistFile {
+	c := make(chan gistFile, 1)
+	name := "setup-3-serial-" + filepath.Base(p.Device)
+	start := time.Now()
+	f, err := openSerial(p)
+	if err != nil {
+		c <- gistFile{name, err.Error(), false, 0}
+		return c
+	}
+	transcript := captureSerial(f)
+	go func() {
+		<-stop
+		// Unblocks the pending read.
+		_ = f.Close()
+		c <- gistFile{name, <-transcript, true, time.Since(start)}
+	}()
+	return c
+}
+
+// openSerial configures the serial port speed if needed and opens it.
+func openSerial(p gohci.SerialPort) (*os.File, error) {
+	if p.Baud != 0 {
+		flag := "-F"
+		if runtime.GOOS == "darwin" {
+			flag = "-f"
+		}
+		/* #nosec G204 */
+		if out, err := exec.Command("stty", flag, p.Device, strconv.Itoa(p.Baud), "raw", "-echo").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to configure %s: %w\n%s", p.Device, err, out)
+		}
+	}
+	/* #nosec G304 */
+	return os.Open(p.Device)
+}
+
+// captureSerial reads r until it fails, keeping the first maxSerialSize bytes.
+func captureSerial(r io.Reader) <-chan string {
+	c := make(chan string, 1)
+	go func() {
+		var b bytes.Buffer
+		buf := make([]byte, 4096)
+		truncated := false
+		for {
+			// Keep reading past the limit so the device never blocks on a full
+			// buffer.
+			n, err := r.Read(buf)
+			if room := maxSerialSize - b.Len(); n > room {
+				b.Write(buf[:room])
+				truncated = true
+			} else {
+				b.Write(buf[:n])
+			}
+			if err != nil {
+				break
+			}
+		}
+		if truncated {
+			b.WriteString("\n<truncated>\n")
+		}
+		c <- b.String()
+	}()
+	return c
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCaptureSerial(t *testing.T) {
+	r, w := io.Pipe()
+	c := captureSerial(r)
+	_, _ = io.WriteString(w, "boot\n")
+	_, _ = io.WriteString(w, "ok\n")
+	_ = w.Close()
+	if got := <-c; got != "boot\nok\n" {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestCaptureSerialTruncated(t *testing.T) {
+	c := captureSerial(strings.NewReader(strings.Repeat("a", maxSerialSize+10)))
+	got := <-c
+	if want := strings.Repeat("a", maxSerialSize) + "\n<truncated>\n"; got != want {
+		t.Fatalf("len %d", len(got))
+	}
+}
@@ -5,9 +5,10 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"net/url"
@@ -15,22 +16,24 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v31/github"
 	fsnotify "gopkg.in/fsnotify.v1"
 	"periph.io/x/gohci"
+	"periph.io/x/gohci/internal/valid"
 )
 
 // runServer runs the web server.
-func runServer(c *gohci.WorkerConfig, wkr worker, fileName string) error {
+//
+// wkr is nil when running as a coordinator.
+func runServer(c *gohci.WorkerConfig, wkr worker, h *jobHistory, fileName string) error {
 	thisFile, err := os.Executable()
 	if err != nil {
 		return err
 	}
-	log.Printf("Executable: %s", thisFile)
-	log.Printf("Name: %s", c.Name)
-	log.Printf("PATH: %s", os.Getenv("PATH"))
+	logServer.Info("config", "executable", thisFile, "name", c.Name, "PATH", os.Getenv("PATH"))
 
 	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", c.Port))
 	if err != nil {
@@ -38,9 +41,36 @@ func runServer(c *gohci.WorkerConfig, wkr worker, fileName string) error {
 	}
 	a := ln.Addr().String()
 	_ = ln.Close()
-	log.Printf("Listening on: %s", a)
+	logServer.Info("listening", "addr", a)
 
-	s := &server{c: c, w: wkr, start: time.Now()}
+	s := &server{c: c, w: wkr, h: h, recent: newRecentSet(time.Hour), start: time.Now(), drain: make(chan struct{})}
+	if c.Coordinator {
+		s.reg = newRegistry(c)
+	}
+	if c.CoordinatorURL != "" {
+		go registerLoop(c, wkr)
+	}
+	if c.RelayURL != "" {
+		go s.relayLoop()
+	}
+	if c.Tunnel != "" {
+		cmd, u, err := startTunnel(c)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}()
+		for _, r := range c.TunnelWebhooks {
+			parts := strings.SplitN(r, "/", 2)
+			if wkr == nil || len(parts) != 2 {
+				logServer.Error("can't update webhook", "repo", r)
+			} else if err := wkr.ensureWebhook(parts[0], parts[1], u+"/"); err != nil {
+				logServer.Error("failed to update webhook", "repo", r, "err", err)
+			}
+		}
+	}
 	http.Handle("/", s)
 	srv := &http.Server{
 		Addr:              a,
@@ -48,36 +78,67 @@ func runServer(c *gohci.WorkerConfig, wkr worker, fileName string) error {
 	}
 	go srv.ListenAndServe()
 
-	w, err := fsnotify.NewWatcher()
-	if err != nil {
-		log.Printf("Failed to initialize watcher: %v", err)
-	} else if err = w.Add(thisFile); err != nil {
-		log.Printf("Failed to initialize watcher: %v", err)
-	} else if err = w.Add(fileName); err != nil {
-		log.Printf("Failed to initialize watcher: %v", err)
+	_ = SetConsoleTitle(fmt.Sprintf("gohci - %s", a))
+	changed := make(chan error, 1)
+	go func() {
+		changed <- waitForChange(thisFile, fileName)
+	}()
+	select {
+	case err = <-changed:
+	case <-s.drain:
+		logServer.Info("draining")
+	}
+	// Ensures no task is running.
+	if s.w != nil {
+		s.w.wait()
 	}
+	// Let the in-flight responses, like the one to /api/v1/drain, complete.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = srv.Shutdown(ctx)
+	return err
+}
 
-	_ = SetConsoleTitle(fmt.Sprintf("gohci - %s", a))
-	if err == nil {
-		select {
-		case <-w.Events:
-		case err = <-w.Errors:
-			log.Printf("Waiting failure: %v", err)
-		}
+// waitForChange returns once one of the files is modified, so the process
+// quits and is restarted with the new executable or configuration.
+//
+// It hangs forever if the files can't be watched.
+func waitForChange(files ...string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		logServer.Error("failed to initialize watcher", "err", err)
 	} else {
+		for _, f := range files {
+			if err = w.Add(f); err != nil {
+				logServer.Error("failed to initialize watcher", "err", err)
+				break
+			}
+		}
+	}
+	if err != nil {
 		// Hang so the server actually run.
 		select {}
 	}
-	// Ensures no task is running.
-	s.w.wait()
+	select {
+	case <-w.Events:
+	case err = <-w.Errors:
+		logServer.Error("waiting failure", "err", err)
+	}
 	return err
 }
 
 // server is the HTTP server and manages the task queue server.
 type server struct {
-	c     *gohci.WorkerConfig
-	w     worker
-	start time.Time
+	c      *gohci.WorkerConfig
+	w      worker
+	h      *jobHistory // Used to store webhook deliveries.
+	recent *recentSet  // Recent deliveries and events, to ignore duplicates.
+	reg    *registry   // Registered workers, only set on a coordinator.
+	start  time.Time
+	sigs   signatureStats
+
+	drain     chan struct{} // Closed by /api/v1/drain.
+	drainOnce sync.Once
 }
 
 // ServeHTTP handles all HTTP requests and triggers a task if relevant.
@@ -86,11 +147,42 @@ type server struct {
 // done so the user is immediately alerted that the task is pending on the
 // host. Only one task runs at a time.
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log.Printf("%-4s %-21s %s", r.Method, r.RemoteAddr, r.URL.Path)
+	logServer.Info("request", "method", r.Method, "remote", r.RemoteAddr, "path", r.URL.Path)
 	defer r.Body.Close()
+	if s.w == nil && (r.URL.Path == "/dashboard" || r.URL.Path == "/feed.atom") {
+		// A coordinator doesn't run jobs.
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Path == "/dashboard" {
+		if r.Method != "GET" {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireView(w, r) {
+			return
+		}
+		s.serveDashboard(w)
+		return
+	}
+	if r.URL.Path == "/feed.atom" {
+		if r.Method != "GET" {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireView(w, r) {
+			return
+		}
+		s.serveFeed(w)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		s.serveAPI(w, r)
+		return
+	}
 	// The path must be the root path.
 	if r.URL.Path != "" && r.URL.Path != "/" {
-		log.Printf("- Unexpected path %s", r.URL.Path)
+		logServer.Warn("unexpected path", "path", r.URL.Path)
 		http.NotFound(w, r)
 		return
 	}
@@ -108,38 +200,67 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	if r.Method != "POST" {
 		http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
-		log.Printf("- invalid method %s", r.Method)
+		logServer.Warn("invalid method", "method", r.Method)
 		return
 	}
 	payload, err := github.ValidatePayload(r, []byte(s.c.WebHookSecret))
 	if err != nil {
 		http.Error(w, "Invalid secret", http.StatusUnauthorized)
-		log.Printf("- invalid secret")
+		logServer.Warn("invalid secret")
+		s.sigs.record(false)
 		return
 	}
 	altPath, superUsers, err := validateArgs(r.URL.Query())
 	if err != nil {
 		// Immediately return an error. This helps catch typos.
-		log.Printf("Invalid query argument, check your webhook URL: %q; %v", r.URL.String(), err)
+		logServer.Warn("invalid query argument, check your webhook URL", "url", r.URL.String(), "err", err)
 		http.Error(w, "Invalid query argument", http.StatusBadRequest)
 		return
 	}
-	s.handleHook(github.WebHookType(r), payload, altPath, superUsers)
+	s.accept(&webhookDelivery{
+		GUID:     github.DeliveryID(r),
+		Event:    github.WebHookType(r),
+		Query:    r.URL.RawQuery,
+		Received: time.Now(),
+		Payload:  payload,
+	}, altPath, superUsers)
 	w.Header().Add("Content-Type", "application/json")
 	_, _ = io.WriteString(w, "{}")
 }
 
+// accept handles a validated webhook delivery.
+func (s *server) accept(d *webhookDelivery, altPath string, superUsers []string) {
+	s.sigs.record(true)
+	if s.c.WebhookMaxDeliveries > 0 {
+		s.h.addDelivery(d, s.c.WebhookMaxDeliveries)
+	}
+	if d.GUID != "" && !s.recent.add("delivery/"+d.GUID) {
+		logServer.Info("ignoring redelivery", "guid", d.GUID)
+	} else if s.reg != nil {
+		s.reg.dispatch(d)
+	} else {
+		s.handleHook(d.Event, d.Payload, altPath, superUsers, true)
+	}
+}
+
 // handleHook handles a validated github webhook.
-func (s *server) handleHook(t string, payload []byte, altPath string, superUsers []string) {
+//
+// When dedupe is true, an event that would trigger the same job as one
+// received recently is ignored.
+func (s *server) handleHook(t string, payload []byte, altPath string, superUsers []string, dedupe bool) {
 	if t == "ping" {
 		return
 	}
-	event, err := github.ParseWebHook(t, payload)
+	event, err := parseHook(t, payload)
 	if err != nil {
-		log.Printf("- invalid payload for hook %s\n%s", t, payload)
+		logServer.Warn("invalid payload", "hook", t, "payload", string(payload))
+		return
+	}
+	logServer.Info("hook", "type", t, "altPath", altPath, "superUsers", strings.Join(superUsers, ","))
+	if k := hookKey(event); dedupe && k != "" && !s.recent.add(k) {
+		logServer.Info("ignoring duplicate event", "key", k)
 		return
 	}
-	log.Printf("altPath=%s; superUsers=%s", altPath, strings.Join(superUsers, ","))
 	// Process the rest asynchronously so the hook doesn't take too long.
 	switch e := event.(type) {
 	case *github.CommitCommentEvent:
@@ -152,23 +273,55 @@ func (s *server) handleHook(t string, payload []byte, altPath string, superUsers
 		s.handlePullRequestReviewComment(e, altPath, superUsers)
 	case *github.PushEvent:
 		s.handlePush(e, altPath)
+	case *github.RepositoryDispatchEvent:
+		s.handleRepositoryDispatch(e, altPath)
+	case *mergeGroupEvent:
+		s.handleMergeGroup(e, altPath)
 	default:
-		log.Printf("- ignoring hook type %s", reflect.TypeOf(e).Elem().Name())
+		logServer.Info("ignoring hook type", "type", reflect.TypeOf(e).Elem().Name())
 	}
 }
 
+// parseHook is github.ParseWebHook with the events go-github doesn't support
+// yet.
+func parseHook(t string, payload []byte) (interface{}, error) {
+	if t == "merge_group" {
+		e := &mergeGroupEvent{}
+		return e, json.Unmarshal(payload, e)
+	}
+	return github.ParseWebHook(t, payload)
+}
+
 // https://developer.github.com/v3/activity/events/types/#commitcommentevent
 func (s *server) handleCommitComment(e *github.CommitCommentEvent, altPath string, superUsers []string) {
-	if strings.TrimSpace(*e.Comment.Body) != "gohci" {
-		log.Printf("- ignoring non 'gohci' commit comment")
+	c := comment{"commit", e.Comment.GetID()}
+	cmd, err := parseCommand(*e.Comment.Body)
+	if err != nil {
+		logServer.Info("ignoring invalid 'gohci' commit comment", "err", err)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
+		return
+	}
+	if cmd == nil {
+		logServer.Info("ignoring non 'gohci' commit comment")
 		return
 	}
-	if !isSuperUser(*e.Sender.Login, superUsers) {
-		log.Printf("- ignoring commit comment from user %q", *e.Sender.Login)
+	if !s.isTrusted(*e.Repo.Owner.Login, *e.Repo.Name, *e.Sender.Login, superUsers) {
+		logServer.Info("ignoring commit comment", "user", *e.Sender.Login)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
+		return
+	}
+	if cmd.name == "cancel" {
+		logServer.Info("ignoring cancel on a commit comment; only supported on PRs")
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
 		return
 	}
 	// TODO(maruel): The commit could be on a branch never fetched?
-	s.w.enqueueCheck(*e.Repo.Owner.Login, *e.Repo.Name, altPath, *e.Comment.CommitID, *e.Repo.Private, 0, nil)
+	spec := jobSpec{org: *e.Repo.Owner.Login, repo: *e.Repo.Name, altPath: altPath, commitHash: *e.Comment.CommitID, useSSH: *e.Repo.Private, comment: c, event: "commit_comment"}
+	if cmd.ref != "" {
+		spec.commitHash = ""
+		spec.ref = cmd.ref
+	}
+	s.w.enqueueCheck(spec)
 }
 
 // https://developer.github.com/v3/activity/events/types/#issuecommentevent
@@ -178,75 +331,135 @@ func (s *server) handleIssueComment(e *github.IssueCommentEvent, altPath string,
 	// token shouldn't have. This is because there is no read access to the
 	// issue without write access.
 	if e.Issue.PullRequestLinks == nil {
-		log.Printf("- ignoring issue #%d", *e.Issue.Number)
+		logServer.Info("ignoring issue", "issue", *e.Issue.Number)
 		return
 	}
 	if *e.Action != "created" && *e.Action != "edited" {
-		log.Printf("- ignoring PR #%d comment", *e.Issue.Number)
+		logServer.Info("ignoring PR comment", "action", *e.Action, "pr", *e.Issue.Number)
 		return
 	}
-	if strings.TrimSpace(*e.Comment.Body) != "gohci" {
-		log.Printf("- ignoring non 'gohci' issue #%d comment", *e.Issue.Number)
+	c := comment{"issue", e.Comment.GetID()}
+	cmd, err := parseCommand(*e.Comment.Body)
+	if err != nil {
+		logServer.Info("ignoring invalid 'gohci' issue comment", "issue", *e.Issue.Number, "err", err)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
+		return
+	}
+	if cmd == nil {
+		logServer.Info("ignoring non 'gohci' issue comment", "issue", *e.Issue.Number)
 		return
 	}
 	// || *e.Issue.AuthorAssociation == "CONTRIBUTOR"
-	if !isSuperUser(*e.Sender.Login, superUsers) {
-		log.Printf("- ignoring issue #%d comment from user %q", *e.Issue.Number, *e.Sender.Login)
+	if !s.isTrusted(*e.Repo.Owner.Login, *e.Repo.Name, *e.Sender.Login, superUsers) {
+		logServer.Info("ignoring issue comment", "issue", *e.Issue.Number, "user", *e.Sender.Login)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
+		return
+	}
+	if cmd.name == "cancel" {
+		s.cancelPR(*e.Repo.Owner.Login, *e.Repo.Name, *e.Issue.Number, *e.Sender.Login, c)
 		return
 	}
 	// The commit hash is not provided. :(
-	s.w.enqueueCheck(*e.Repo.Owner.Login, *e.Repo.Name, altPath, "", *e.Repo.Private, *e.Issue.Number, nil)
+	spec := jobSpec{org: *e.Repo.Owner.Login, repo: *e.Repo.Name, altPath: altPath, useSSH: *e.Repo.Private, pullID: *e.Issue.Number, comment: c, event: "issue_comment"}
+	if cmd.ref != "" {
+		spec.pullID = 0
+		spec.ref = cmd.ref
+	}
+	s.w.enqueueCheck(spec)
 }
 
 // https://developer.github.com/v3/activity/events/types/#pullrequestevent
 func (s *server) handlePullRequest(e *github.PullRequestEvent, altPath string, superUsers []string) {
-	if *e.Action != "opened" && *e.Action != "synchronize" {
-		log.Printf("- ignoring action %q for PR from %q", *e.Action, *e.Sender.Login)
+	// "ready_for_review" is sent when a draft PR is marked as ready, which is
+	// when projects skipping drafts want it tested.
+	if *e.Action != "opened" && *e.Action != "synchronize" && *e.Action != "ready_for_review" {
+		logServer.Info("ignoring PR action", "action", *e.Action, "user", *e.Sender.Login)
 		return
 	}
-	log.Printf("- PR %s #%d %s %s", *e.Repo.FullName, *e.PullRequest.Number, *e.Sender.Login, *e.Action)
+	logServer.Info("PR", "repo", *e.Repo.FullName, "pr", *e.PullRequest.Number, "user", *e.Sender.Login, "action", *e.Action)
 	// TODO(maruel): If a reviewer is set, it has to be set by a repository
 	// owner (?) If so, then it would be safe to run.
-	if !isSuperUser(*e.Sender.Login, superUsers) {
-		log.Printf("- ignoring PR from not super user %q", *e.PullRequest.Head.Repo.FullName)
-		return
+	spec := jobSpec{org: *e.Repo.Owner.Login, repo: *e.Repo.Name, altPath: altPath, commitHash: *e.PullRequest.Head.SHA, useSSH: *e.Repo.Private, pullID: *e.PullRequest.Number, draft: e.PullRequest.GetDraft(), event: "pull_request"}
+	if !s.isTrusted(*e.Repo.Owner.Login, *e.Repo.Name, *e.Sender.Login, superUsers) {
+		if len(s.c.ForkChecks) == 0 {
+			logServer.Info("ignoring PR from not super user", "user", *e.Sender.Login, "head", *e.PullRequest.Head.Repo.FullName)
+			return
+		}
+		logServer.Info("restricted PR from not super user", "user", *e.Sender.Login, "head", *e.PullRequest.Head.Repo.FullName)
+		spec.restricted = true
 	}
-	s.w.enqueueCheck(*e.Repo.Owner.Login, *e.Repo.Name, altPath, *e.PullRequest.Head.SHA, *e.Repo.Private, *e.PullRequest.Number, nil)
+	s.w.enqueueCheck(spec)
 }
 
 // https://developer.github.com/v3/activity/events/types/#pullrequestreviewcommentevent
 func (s *server) handlePullRequestReviewComment(e *github.PullRequestReviewCommentEvent, altPath string, superUsers []string) {
 	if *e.Action != "created" && *e.Action != "edited" {
-		log.Printf("- ignoring action %s for PR #%d comment", *e.Action, *e.PullRequest.Number)
+		logServer.Info("ignoring PR comment", "action", *e.Action, "pr", *e.PullRequest.Number)
+		return
+	}
+	c := comment{"review", e.Comment.GetID()}
+	cmd, err := parseCommand(*e.Comment.Body)
+	if err != nil {
+		logServer.Info("ignoring invalid 'gohci' PR comment", "pr", *e.PullRequest.Number, "err", err)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
 		return
 	}
-	if strings.TrimSpace(*e.Comment.Body) != "gohci" {
-		log.Printf("- ignoring non 'gohci' issue #%d comment", *e.PullRequest.Number)
+	if cmd == nil {
+		logServer.Info("ignoring non 'gohci' PR comment", "pr", *e.PullRequest.Number)
 		return
 	}
 	// || *e.PullRequest.AuthorAssociation == "CONTRIBUTOR"
-	if !isSuperUser(*e.Sender.Login, superUsers) {
-		log.Printf("- ignoring issue #%d comment from user %q", *e.PullRequest.Number, *e.Sender.Login)
+	if !s.isTrusted(*e.Repo.Owner.Login, *e.Repo.Name, *e.Sender.Login, superUsers) {
+		logServer.Info("ignoring PR comment", "pr", *e.PullRequest.Number, "user", *e.Sender.Login)
+		s.w.react(*e.Repo.Owner.Login, *e.Repo.Name, c, "-1")
+		return
+	}
+	if cmd.name == "cancel" {
+		s.cancelPR(*e.Repo.Owner.Login, *e.Repo.Name, *e.PullRequest.Number, *e.Sender.Login, c)
+		return
+	}
+	spec := jobSpec{org: *e.Repo.Owner.Login, repo: *e.Repo.Name, altPath: altPath, commitHash: *e.PullRequest.Head.SHA, useSSH: *e.Repo.Private, pullID: *e.PullRequest.Number, comment: c, event: "pull_request_review_comment"}
+	if cmd.ref != "" {
+		spec.commitHash = ""
+		spec.pullID = 0
+		spec.ref = cmd.ref
+	}
+	s.w.enqueueCheck(spec)
+}
+
+// cancelPR handles "gohci: cancel" on a PR. It cancels the PR's pending and
+// running jobs.
+func (s *server) cancelPR(org, repo string, pullID int, user string, c comment) {
+	n := s.w.cancelPR(org, repo, pullID, "cancelled by @"+user)
+	logServer.Info("cancel", "repo", org+"/"+repo, "pr", pullID, "user", user, "jobs", n)
+	if n == 0 {
+		s.w.react(org, repo, c, "confused")
 		return
 	}
-	s.w.enqueueCheck(*e.Repo.Owner.Login, *e.Repo.Name, altPath, *e.PullRequest.Head.SHA, *e.Repo.Private, *e.PullRequest.Number, nil)
+	s.w.react(org, repo, c, "+1")
 }
 
 // https://developer.github.com/v3/activity/events/types/#pushevent
 func (s *server) handlePush(e *github.PushEvent, altPath string) {
 	if e.HeadCommit == nil {
-		log.Printf("- Push %s %s <deleted>", *e.Repo.FullName, *e.Ref)
+		logServer.Info("push deleted", "repo", *e.Repo.FullName, "ref", *e.Ref)
 		return
 	}
-	log.Printf("- Push %s %s %s", *e.Repo.FullName, *e.Ref, *e.HeadCommit.ID)
-	// TODO(maruel): Potentially leverage e.Repo.DefaultBranch or
-	// e.Repo.MasterBranch?
+	logServer.Info("push", "repo", *e.Repo.FullName, "ref", *e.Ref, "commit", *e.HeadCommit.ID)
 	if !strings.HasPrefix(*e.Ref, "refs/heads/") {
-		log.Printf("- ignoring branch %q for push", *e.Ref)
+		logServer.Info("ignoring push", "ref", *e.Ref)
 		return
 	}
+	// Only assign blame on the default branch, which may not be "master".
+	branch := e.Repo.GetDefaultBranch()
+	if branch == "" {
+		branch = e.Repo.GetMasterBranch()
+	}
+	if branch == "" {
+		branch = s.w.defaultBranch(*e.Repo.Owner.Name, *e.Repo.Name)
+	}
 	var blame []string
-	if *e.Ref == "refs/heads/master" {
+	if branch != "" && *e.Ref == "refs/heads/"+branch {
 		author := *e.HeadCommit.Author.Login
 		committer := *e.HeadCommit.Committer.Login
 		if author != committer {
@@ -255,7 +468,79 @@ func (s *server) handlePush(e *github.PushEvent, altPath string) {
 			blame = []string{author}
 		}
 	}
-	s.w.enqueueCheck(*e.Repo.Owner.Name, *e.Repo.Name, altPath, *e.HeadCommit.ID, *e.Repo.Private, 0, blame)
+	s.w.enqueueCheck(jobSpec{org: *e.Repo.Owner.Name, repo: *e.Repo.Name, altPath: altPath, commitHash: *e.HeadCommit.ID, ref: *e.Ref, useSSH: *e.Repo.Private, blame: blame, event: "push"})
+}
+
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads#repository_dispatch
+//
+// The client_payload may contain "commit", "ref", "pr" and "checks", the
+// 1-based indexes of the checks to run. Sending the event requires write
+// access to the repository so the sender is trusted.
+func (s *server) handleRepositoryDispatch(e *github.RepositoryDispatchEvent, altPath string) {
+	if e.GetAction() != s.c.DispatchEventType {
+		logServer.Info("ignoring repository_dispatch", "event_type", e.GetAction())
+		return
+	}
+	var p struct {
+		Commit string
+		Ref    string
+		PR     int
+		Checks []int
+	}
+	if len(e.ClientPayload) != 0 {
+		if err := json.Unmarshal(e.ClientPayload, &p); err != nil {
+			logServer.Warn("invalid repository_dispatch client_payload", "err", err)
+			return
+		}
+	}
+	if p.Commit != "" && (len(p.Commit) != 40 || !isSubset(p.Commit, "0123456789abcdef")) {
+		logServer.Warn("invalid repository_dispatch commit", "commit", p.Commit)
+		return
+	}
+	if p.Ref != "" && !valid.Ref(p.Ref) {
+		logServer.Warn("invalid repository_dispatch ref", "ref", p.Ref)
+		return
+	}
+	logServer.Info("repository_dispatch", "repo", e.Repo.GetFullName(), "commit", p.Commit, "ref", p.Ref, "pr", p.PR)
+	s.w.enqueueCheck(jobSpec{org: e.Repo.GetOwner().GetLogin(), repo: e.Repo.GetName(), altPath: altPath, commitHash: p.Commit, ref: p.Ref, useSSH: e.Repo.GetPrivate(), pullID: p.PR, checks: p.Checks, event: "repository_dispatch"})
+}
+
+// mergeGroupEvent is the merge_group event sent by the merge queue.
+//
+// https://docs.github.com/en/webhooks/webhook-events-and-payloads#merge_group
+type mergeGroupEvent struct {
+	Action     string `json:"action"`
+	MergeGroup struct {
+		HeadSHA string `json:"head_sha"`
+		HeadRef string `json:"head_ref"`
+		BaseRef string `json:"base_ref"`
+	} `json:"merge_group"`
+	Repo *github.Repository `json:"repository"`
+}
+
+// handleMergeGroup tests the merge queue's head commit, the PR merged on top
+// of the base branch and the PRs ahead in the queue. The status uses the
+// worker's name as the context, same as for the PR, so it can be a required
+// check.
+//
+// Only users with write access can add a PR to the merge queue so the commit
+// is trusted.
+func (s *server) handleMergeGroup(e *mergeGroupEvent, altPath string) {
+	if e.Action != "checks_requested" {
+		logServer.Info("ignoring merge_group", "action", e.Action)
+		return
+	}
+	g := &e.MergeGroup
+	if len(g.HeadSHA) != 40 || !isSubset(g.HeadSHA, "0123456789abcdef") {
+		logServer.Warn("invalid merge_group head_sha", "commit", g.HeadSHA)
+		return
+	}
+	if !valid.Ref(g.HeadRef) {
+		logServer.Warn("invalid merge_group head_ref", "ref", g.HeadRef)
+		return
+	}
+	logServer.Info("merge_group", "repo", e.Repo.GetFullName(), "commit", g.HeadSHA, "ref", g.HeadRef, "base", g.BaseRef)
+	s.w.enqueueCheck(jobSpec{org: e.Repo.GetOwner().GetLogin(), repo: e.Repo.GetName(), altPath: altPath, commitHash: g.HeadSHA, ref: g.HeadRef, useSSH: e.Repo.GetPrivate(), event: "merge_group"})
 }
 
 //
@@ -292,13 +577,22 @@ func validateArgs(values url.Values) (string, []string, error) {
 			if len(s) == 0 {
 				return "", nil, fmt.Errorf("passing an empty superUser")
 			}
+			// "org/team-name" grants access to the members of the team.
+			user := s
+			if i := strings.IndexByte(s, '/'); i != -1 {
+				user = s[:i]
+				team := s[i+1:]
+				if len(team) == 0 || !isSubset(team, "abcdefghijklmnopqrstuvwxyz0123456789-_") {
+					return "", nil, fmt.Errorf("superUser team contains unexpected characters: %q", s)
+				}
+			}
 			// From https://github.com/join:
 			// "Username may only contain alphanumeric characters or single hyphens,
 			// and cannot begin or end with a hyphen"
-			if !isSubset(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-") {
+			if len(user) == 0 || !isSubset(user, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-") {
 				return "", nil, fmt.Errorf("superUser contains unexpected characters: %q", s)
 			}
-			if strings.HasPrefix(s, "-") || strings.HasSuffix(s, "-") {
+			if strings.HasPrefix(user, "-") || strings.HasSuffix(user, "-") {
 				return "", nil, fmt.Errorf("superUser starts or ends with a dash: %q", s)
 			}
 			superUsers = append(superUsers, s)
@@ -320,6 +614,31 @@ func isSubset(s, allowed string) bool {
 	return true
 }
 
+// isTrusted returns true if the user can trigger tasks on the repository.
+//
+// The user is trusted when listed in superUsers, is a member of a team listed
+// as "org/team-name" in superUsers or, with TrustCollaborators, when GitHub
+// reports that the user has write access.
+func (s *server) isTrusted(org, repo, user string, superUsers []string) bool {
+	return isTrustedUser(s.c, s.w, org, repo, user, superUsers)
+}
+
+// isTrustedUser implements isTrusted for both the webhooks and the poller.
+func isTrustedUser(c *gohci.WorkerConfig, w worker, org, repo, user string, superUsers []string) bool {
+	if p := findProject(c, org, repo); p != nil {
+		superUsers = append(superUsers[:len(superUsers):len(superUsers)], p.SuperUsers...)
+	}
+	if isSuperUser(user, superUsers) {
+		return true
+	}
+	for _, t := range superUsers {
+		if i := strings.IndexByte(t, '/'); i != -1 && w.inTeam(t[:i], t[i+1:], user) {
+			return true
+		}
+	}
+	return c.TrustCollaborators && w.canWrite(org, repo, user)
+}
+
 // isSuperUser returns true if the user can trigger tasks.
 //
 // superUsers is a list of github accounts that can trigger a run. In practice
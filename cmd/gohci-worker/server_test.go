@@ -0,0 +1,136 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+func TestValidateArgs(t *testing.T) {
+	v := url.Values{"superUsers": {"maruel,periph/maintainers,a-b/c_d"}}
+	_, users, err := validateArgs(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"maruel", "periph/maintainers", "a-b/c_d"}; !reflect.DeepEqual(users, expected) {
+		t.Fatalf("%v != %v", users, expected)
+	}
+	for _, s := range []string{"", "-a", "a/", "/b", "a/B", "a/b/c", "a b"} {
+		if _, _, err := validateArgs(url.Values{"superUsers": {s}}); err == nil {
+			t.Fatalf("validateArgs(%q) should have failed", s)
+		}
+	}
+}
+
+func TestCanView(t *testing.T) {
+	s := &server{c: &gohci.WorkerConfig{WebHookSecret: "secret"}}
+	r := httptest.NewRequest("GET", "/dashboard", nil)
+	if !s.canView(r) {
+		t.Fatal("public by default")
+	}
+	s.c.DashboardUsers = []string{"alice:pw"}
+	if s.canView(r) {
+		t.Fatal("expected unauthorized")
+	}
+	r.SetBasicAuth("alice", "bad")
+	if s.canView(r) {
+		t.Fatal("expected unauthorized")
+	}
+	r.SetBasicAuth("alice", "pw")
+	if !s.canView(r) {
+		t.Fatal("expected authorized")
+	}
+	r.Header.Set("Authorization", "Bearer secret")
+	if !s.canView(r) {
+		t.Fatal("expected authorized")
+	}
+}
+
+func TestServePause(t *testing.T) {
+	wkr := &workerQueue{}
+	s := &server{c: &gohci.WorkerConfig{WebHookSecret: "secret"}, w: wkr}
+	do := func(action, auth string) int {
+		r := httptest.NewRequest("POST", "/api/v1/"+action, nil)
+		if auth != "" {
+			r.Header.Set("Authorization", "Bearer "+auth)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w.Code
+	}
+	if c := do("pause", ""); c != 401 || wkr.isPaused() {
+		t.Fatal(c)
+	}
+	if c := do("pause", "secret"); c != 200 || !wkr.isPaused() {
+		t.Fatal(c)
+	}
+	if c := do("resume", "secret"); c != 200 || wkr.isPaused() {
+		t.Fatal(c)
+	}
+}
+
+func TestServeDrain(t *testing.T) {
+	wkr := &workerQueue{}
+	s := &server{c: &gohci.WorkerConfig{WebHookSecret: "secret"}, w: wkr, drain: make(chan struct{})}
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest("POST", "/api/v1/drain", nil)
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != 200 || !wkr.isPaused() {
+			t.Fatal(w.Code)
+		}
+	}
+	select {
+	case <-s.drain:
+	default:
+		t.Fatal("expected drain to be closed")
+	}
+}
+
+// specWorker records the enqueued jobs.
+type specWorker struct {
+	worker
+	specs []jobSpec
+}
+
+func (w *specWorker) enqueueCheck(s jobSpec) {
+	w.specs = append(w.specs, s)
+}
+
+func TestHandleRepositoryDispatch(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	data := []struct {
+		payload string
+		want    bool
+	}{
+		{`{}`, true},
+		{`{"commit":"` + sha + `","ref":"refs/heads/main"}`, true},
+		{`{"commit":"abc"}`, false},
+		{`{"commit":"` + sha + `0"}`, false},
+		{`{"commit":"0123456789ABCDEF0123456789ABCDEF01234567"}`, false},
+		{`{"ref":"bad..ref"}`, false},
+	}
+	for i, l := range data {
+		w := &specWorker{}
+		s := &server{c: &gohci.WorkerConfig{DispatchEventType: "gohci"}, w: w}
+		e := &github.RepositoryDispatchEvent{
+			Action:        github.String("gohci"),
+			ClientPayload: json.RawMessage(l.payload),
+			Repo:          &github.Repository{Name: github.String("gohci"), Owner: &github.User{Login: github.String("periph")}},
+		}
+		s.handleRepositoryDispatch(e, "")
+		if got := len(w.specs) == 1; got != l.want {
+			t.Fatalf("#%d: %s: got %v", i, l.payload, w.specs)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"periph.io/x/gohci/internal/valid"
+)
+
+// verifySignature verifies that the commit in dir is signed by one of the
+// keys.
+//
+// keys are SSH public keys, e.g. "ssh-ed25519 AAAA...", or GPG key
+// fingerprints. The GPG public keys must be in the worker's keyring. Returns
+// the output of git verify-commit.
+func verifySignature(dir, commit string, keys []string) (string, error) {
+	// Only the SSH keys listed here are accepted. The principal is not
+	// verified.
+	f, err := os.CreateTemp("", "gohci-allowed-signers")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	var fps []string
+	for _, k := range keys {
+		if valid.SSHKey(k) {
+			fmt.Fprintf(f, "* %s\n", k)
+		} else {
+			fps = append(fps, valid.NormalizeFingerprint(k))
+		}
+	}
+	if err = f.Close(); err != nil {
+		return "", err
+	}
+	/* #nosec G204 */
+	c := exec.Command("git", "-C", dir, "-c", "gpg.ssh.allowedSignersFile="+f.Name(), "verify-commit", "--raw", commit)
+	b, err := c.CombinedOutput()
+	out := string(b)
+	if err != nil {
+		if strings.TrimSpace(out) == "" {
+			return out, errors.New("commit is not signed")
+		}
+		return out, errors.New("commit signature is not valid")
+	}
+	if !strings.Contains(out, "[GNUPG:] ") {
+		// git already verified the SSH key against the allowed signers.
+		return out, nil
+	}
+	// gpg accepts any key in the keyring; only accept the configured ones.
+	for _, l := range strings.Split(out, "\n") {
+		// VALIDSIG <fingerprint> <date> ... <primary key fingerprint>
+		if fields := strings.Fields(l); len(fields) > 2 && fields[0] == "[GNUPG:]" && fields[1] == "VALIDSIG" {
+			for _, fp := range fps {
+				if strings.EqualFold(fields[2], fp) || strings.EqualFold(fields[len(fields)-1], fp) {
+					return out, nil
+				}
+			}
+		}
+	}
+	return out, errors.New("commit is signed by an unknown GPG key")
+}
+
+// verifyCommits verifies that all the commits in from..to in dir are signed
+// by one of the keys. Returns the output of the first failed verification.
+func verifyCommits(dir, from, to string, keys []string) (string, error) {
+	/* #nosec G204 */
+	b, err := exec.Command("git", "-C", dir, "rev-list", from+".."+to).CombinedOutput()
+	if err != nil {
+		return string(b), err
+	}
+	for _, c := range strings.Fields(string(b)) {
+		if out, err := verifySignature(dir, c, keys); err != nil {
+			return out, fmt.Errorf("%s: %w", c, err)
+		}
+	}
+	return "", nil
+}
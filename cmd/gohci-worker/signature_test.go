@@ -0,0 +1,52 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen is not installed")
+	}
+	origin, commits := newTestOrigin(t)
+	keys := t.TempDir()
+	var pubs []string
+	for _, n := range []string{"good", "bad"} {
+		p := filepath.Join(keys, n)
+		if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", p).CombinedOutput(); err != nil {
+			t.Fatalf("%v\n%s", err, out)
+		}
+		b, err := os.ReadFile(p + ".pub")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs = append(pubs, strings.TrimSpace(string(b)))
+	}
+	c := exec.Command("git", "-C", origin, "-c", "user.name=a", "-c", "user.email=a@a", "-c", "gpg.format=ssh", "-c", "user.signingkey="+filepath.Join(keys, "good"), "commit", "--quiet", "--allow-empty", "-S", "-m", "signed")
+	if out, err := c.CombinedOutput(); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if out, err := verifySignature(origin, "HEAD", pubs[:1]); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if _, err := verifySignature(origin, "HEAD", pubs[1:]); err == nil || err.Error() != "commit signature is not valid" {
+		t.Fatal(err)
+	}
+	if _, err := verifySignature(origin, commits[1], pubs[:1]); err == nil || err.Error() != "commit is not signed" {
+		t.Fatal(err)
+	}
+	if out, err := verifyCommits(origin, commits[1], "HEAD", pubs[:1]); err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+	if _, err := verifyCommits(origin, commits[0], "HEAD", pubs[:1]); err == nil || err.Error() != commits[1]+": commit is not signed" {
+		t.Fatal(err)
+	}
+}
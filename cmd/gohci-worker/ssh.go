@@ -0,0 +1,40 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeKnownHosts writes the pinned SSH host keys to wd/known_hosts and
+// returns its path.
+//
+// It returns "" when no key is pinned, in which case the user's ssh
+// configuration is used as-is.
+func writeKnownHosts(wd string, lines []string) (string, error) {
+	p := filepath.Join(wd, "known_hosts")
+	if len(lines) == 0 {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		return "", nil
+	}
+	if err := os.WriteFile(p, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// sshCommand returns the GIT_SSH_COMMAND environment variable to use the
+// pinned host keys in knownHosts.
+//
+// BatchMode makes ssh fail right away instead of hanging on a prompt when the
+// host key doesn't match.
+func sshCommand(knownHosts string) string {
+	// Forward slashes work on Windows too and don't need escaping.
+	return "GIT_SSH_COMMAND=ssh -o BatchMode=yes -o StrictHostKeyChecking=yes -o UserKnownHostsFile=\"" + filepath.ToSlash(knownHosts) + "\""
+}
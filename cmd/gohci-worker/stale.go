@@ -0,0 +1,121 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// procRegistry records the process groups of the running checks in a file,
+// so the ones left behind, e.g. when the worker crashed, are killed before
+// the next job.
+//
+// Each group is recorded with the start time of its leader and the file with
+// the boot ID, so a reused PID, e.g. after a reboot, is never killed.
+type procRegistry struct {
+	path string
+
+	mu     sync.Mutex
+	active map[int]string
+}
+
+func newProcRegistry(path string) *procRegistry {
+	return &procRegistry{path: path, active: map[int]string{}}
+}
+
+// add records a process group started by a check.
+func (p *procRegistry) add(pgid int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[pgid] = processStartTime(pgid)
+	p.saveLocked(p.loadLocked())
+}
+
+// remove forgets a process group that was killed.
+func (p *procRegistry) remove(pgid int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.active, pgid)
+	l := p.loadLocked()
+	delete(l, pgid)
+	p.saveLocked(l)
+}
+
+// killStale kills the recorded process groups that are not from a running
+// check and returns the ones that were still alive.
+//
+// A group is only killed if its leader still has the recorded start time.
+func (p *procRegistry) killStale() []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var killed []int
+	for pgid, start := range p.loadLocked() {
+		if _, ok := p.active[pgid]; ok || start == "" || processStartTime(pgid) != start {
+			continue
+		}
+		if killStaleGroup(pgid) {
+			killed = append(killed, pgid)
+		}
+	}
+	sort.Ints(killed)
+	p.saveLocked(nil)
+	return killed
+}
+
+// loadLocked returns the recorded groups and their leader's start time. It
+// returns nothing if they were recorded before the last boot or if the boot
+// ID is unknown.
+func (p *procRegistry) loadLocked() map[int]string {
+	out := map[int]string{}
+	/* #nosec G304 */
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return out
+	}
+	lines := strings.Split(string(b), "\n")
+	if boot := bootID(); boot == "" || lines[0] != "boot "+boot {
+		return out
+	}
+	for _, l := range lines[1:] {
+		id, start, ok := strings.Cut(l, " ")
+		if i, err := strconv.Atoi(id); ok && err == nil && i > 0 && start != "-" {
+			out[i] = start
+		}
+	}
+	return out
+}
+
+// saveLocked writes the groups in l and the active ones.
+func (p *procRegistry) saveLocked(l map[int]string) {
+	all := map[int]string{}
+	for i, s := range l {
+		all[i] = s
+	}
+	for i, s := range p.active {
+		all[i] = s
+	}
+	ids := make([]int, 0, len(all))
+	for i := range all {
+		ids = append(ids, i)
+	}
+	sort.Ints(ids)
+	var b strings.Builder
+	fmt.Fprintf(&b, "boot %s\n", bootID())
+	for _, i := range ids {
+		s := all[i]
+		if s == "" {
+			s = "-"
+		}
+		fmt.Fprintf(&b, "%d %s\n", i, s)
+	}
+	if err := os.WriteFile(p.path, []byte(b.String()), 0o600); err != nil {
+		logJob.Warn("failed to save the process registry", "err", err)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "processes.txt")
+	header := "boot " + bootID() + "\n"
+	p := newProcRegistry(path)
+	p.add(0x7ffffff0)
+	p.add(0x7ffffff1)
+	p.remove(0x7ffffff0)
+	if b, err := os.ReadFile(path); err != nil || string(b) != header+"2147483633 -\n" {
+		t.Fatalf("%q %v", b, err)
+	}
+	// A running check is never killed.
+	if got := p.killStale(); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != header+"2147483633 -\n" {
+		t.Fatalf("%q %v", b, err)
+	}
+
+	// A new worker finds the previous entries; these groups don't exist.
+	if got := newProcRegistry(path).killStale(); len(got) != 0 {
+		t.Fatal(got)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != header {
+		t.Fatalf("%q %v", b, err)
+	}
+}
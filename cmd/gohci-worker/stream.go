@@ -0,0 +1,141 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// streamMaxBacklog is the number of bytes of output kept for the late
+	// subscribers.
+	streamMaxBacklog = 1 << 20
+	// streamMaxLine is the length at which a line without a newline, e.g. a
+	// progress bar, is split.
+	streamMaxLine = 64 << 10
+)
+
+// logStream broadcasts the output of a job line by line to any number of
+// subscribers.
+//
+// It implements io.Writer. The last lines are kept so late subscribers get the
+// recent output, prefixed with the number of lines dropped, if any.
+type logStream struct {
+	mu      sync.Mutex
+	partial []byte
+	lines   []string
+	size    int // Sum of the length of lines.
+	dropped int // Number of lines removed from lines.
+	subs    map[chan string]struct{}
+	closed  bool
+}
+
+func newLogStream() *logStream {
+	return &logStream{subs: map[chan string]struct{}{}}
+}
+
+// Write implements io.Writer.
+func (l *logStream) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return len(p), nil
+	}
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i == -1 {
+			break
+		}
+		l.sendLocked(string(normalizeUTF8(bytes.TrimSuffix(l.partial[:i], []byte("\r")))))
+		l.partial = l.partial[i+1:]
+	}
+	for len(l.partial) > streamMaxLine {
+		// Don't cut a rune in half.
+		i := streamMaxLine
+		for i > streamMaxLine-utf8.UTFMax && !utf8.RuneStart(l.partial[i]) {
+			i--
+		}
+		l.sendLocked(string(normalizeUTF8(l.partial[:i])))
+		l.partial = l.partial[i:]
+	}
+	return len(p), nil
+}
+
+// subscribe returns the lines so far and a channel to receive the next ones.
+//
+// The channel is closed when the stream is closed, when the subscriber is too
+// slow or when cancel is called.
+func (l *logStream) subscribe() ([]string, <-chan string, func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan string, 256)
+	var backlog []string
+	if l.dropped != 0 {
+		backlog = append(backlog, fmt.Sprintf("[%d earlier lines dropped]", l.dropped))
+	}
+	backlog = append(backlog, l.lines...)
+	if l.closed {
+		close(ch)
+		return backlog, ch, func() {}
+	}
+	l.subs[ch] = struct{}{}
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subs[ch]; ok {
+			delete(l.subs, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, cancel
+}
+
+// close flushes any partial line and closes all subscribers.
+func (l *logStream) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	if len(l.partial) != 0 {
+		l.sendLocked(string(normalizeUTF8(l.partial)))
+		l.partial = nil
+	}
+	l.closed = true
+	for ch := range l.subs {
+		close(ch)
+	}
+	l.subs = nil
+}
+
+// done returns true once the stream is closed.
+func (l *logStream) done() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+func (l *logStream) sendLocked(line string) {
+	l.lines = append(l.lines, line)
+	l.size += len(line)
+	for l.size > streamMaxBacklog {
+		l.size -= len(l.lines[0])
+		l.lines = l.lines[1:]
+		l.dropped++
+	}
+	for ch := range l.subs {
+		select {
+		case ch <- line:
+		default:
+			// Too slow, drop it so it doesn't block the job.
+			delete(l.subs, ch)
+			close(ch)
+		}
+	}
+}
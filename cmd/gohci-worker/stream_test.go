@@ -0,0 +1,53 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogStream(t *testing.T) {
+	l := newLogStream()
+	_, _ = io.WriteString(l, "a\nb")
+	backlog, ch, cancel := l.subscribe()
+	defer cancel()
+	if !reflect.DeepEqual(backlog, []string{"a"}) {
+		t.Fatalf("unexpected backlog %q", backlog)
+	}
+	_, _ = io.WriteString(l, "c\r\nd")
+	l.close()
+	var got []string
+	for line := range ch {
+		got = append(got, line)
+	}
+	if expected := []string{"bc", "d"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("got %q; expected %q", got, expected)
+	}
+	if !l.done() {
+		t.Fatal("expected done")
+	}
+}
+
+func TestLogStreamBacklog(t *testing.T) {
+	l := newLogStream()
+	defer l.close()
+	// A line without newline is split.
+	_, _ = io.WriteString(l, strings.Repeat("a", streamMaxLine+1))
+	for i := 0; i < streamMaxBacklog/streamMaxLine; i++ {
+		_, _ = io.WriteString(l, strings.Repeat("b", streamMaxLine-1)+"\n")
+	}
+	backlog, _, cancel := l.subscribe()
+	defer cancel()
+	if len(backlog) != streamMaxBacklog/streamMaxLine+1 {
+		t.Fatal(len(backlog))
+	}
+	// The first line was dropped, the second is "a" followed by b's.
+	if backlog[0] != "[1 earlier lines dropped]" || !strings.HasPrefix(backlog[1], "ab") {
+		t.Fatal(backlog[0], backlog[1][:2])
+	}
+}
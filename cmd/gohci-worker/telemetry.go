@@ -0,0 +1,108 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cpuTemp returns the CPU temperature, e.g. "48.3°C", or "" if not
+// available.
+func cpuTemp() string {
+	b, err := os.ReadFile("/sys/class/thermal/thermal_zone0/temp")
+	if err != nil {
+		return ""
+	}
+	m, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%.1f°C", float64(m)/1000)
+}
+
+// throttled returns the value reported by "vcgencmd get_throttled" on a
+// Raspberry Pi, or 0 and false if not available.
+func throttled() (uint32, bool) {
+	out, err := exec.Command("vcgencmd", "get_throttled").Output()
+	if err != nil {
+		return 0, false
+	}
+	s := strings.TrimPrefix(strings.TrimSpace(string(out)), "throttled=")
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// throttledBits are the bits of "vcgencmd get_throttled". The same bits
+// shifted by 16 mean it occurred since boot.
+//
+// https://www.raspberrypi.com/documentation/computers/os.html#get_throttled
+var throttledBits = []string{"under-voltage", "arm frequency capped", "throttled", "soft temperature limit"}
+
+// describeThrottled returns a human readable description of v.
+func describeThrottled(v uint32) string {
+	var now, past []string
+	for i, n := range throttledBits {
+		if v&(1<<i) != 0 {
+			now = append(now, n)
+		}
+		if v&(1<<(i+16)) != 0 {
+			past = append(past, n)
+		}
+	}
+	out := fmt.Sprintf("0x%x", v)
+	if len(now) != 0 {
+		out += "; now: " + strings.Join(now, ", ")
+	}
+	if len(past) != 0 {
+		out += "; since boot: " + strings.Join(past, ", ")
+	}
+	return out
+}
+
+// telemetry returns the current temperature and throttling state, as
+// available, on a single line. It returns "" if nothing is available.
+func telemetry() string {
+	var out []string
+	if t := cpuTemp(); t != "" {
+		out = append(out, "temp="+t)
+	}
+	if v, ok := throttled(); ok {
+		out = append(out, "throttled="+describeThrottled(v))
+	}
+	return strings.Join(out, " ")
+}
+
+// startTelemetry samples the telemetry every interval until stop is closed.
+// The samples are then sent on the returned channel. It returns nil if no
+// telemetry is available.
+func startTelemetry(interval time.Duration, stop <-chan struct{}) <-chan string {
+	if telemetry() == "" {
+		return nil
+	}
+	c := make(chan string, 1)
+	go func() {
+		var b strings.Builder
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			fmt.Fprintf(&b, "%s %s\n", time.Now().Format(time.TimeOnly), telemetry())
+			select {
+			case <-t.C:
+			case <-stop:
+				c <- b.String()
+				return
+			}
+		}
+	}()
+	return c
+}
@@ -0,0 +1,23 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestDescribeThrottled(t *testing.T) {
+	data := []struct {
+		v    uint32
+		want string
+	}{
+		{0, "0x0"},
+		{0x50005, "0x50005; now: under-voltage, throttled; since boot: under-voltage, throttled"},
+		{0x80000, "0x80000; since boot: soft temperature limit"},
+	}
+	for _, l := range data {
+		if got := describeThrottled(l.v); got != l.want {
+			t.Fatalf("describeThrottled(0x%x) = %q; want %q", l.v, got, l.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// timestampWriter prefixes each line with the time elapsed since the
+// command started, e.g. "[00:03.241] ".
+type timestampWriter struct {
+	w       io.Writer
+	elapsed func() time.Duration
+
+	mu  sync.Mutex
+	mid bool // mid is true when the last write didn't end with a newline.
+}
+
+func newTimestampWriter(w io.Writer, start time.Time) *timestampWriter {
+	return &timestampWriter{w: w, elapsed: func() time.Duration { return time.Since(start) }}
+}
+
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(p)
+	var b []byte
+	for len(p) != 0 {
+		if !t.mid {
+			b = append(b, formatElapsed(t.elapsed())...)
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i == -1 {
+			b = append(b, p...)
+			t.mid = true
+			break
+		}
+		b = append(b, p[:i+1]...)
+		p = p[i+1:]
+		t.mid = false
+	}
+	if _, err := t.w.Write(b); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// formatElapsed returns the line prefix for d.
+func formatElapsed(d time.Duration) string {
+	m := d / time.Minute
+	d -= m * time.Minute
+	return fmt.Sprintf("[%02d:%02d.%03d] ", m, d/time.Second, (d%time.Second)/time.Millisecond)
+}
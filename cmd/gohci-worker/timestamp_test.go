@@ -0,0 +1,33 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimestampWriter(t *testing.T) {
+	var buf bytes.Buffer
+	d := 3241 * time.Millisecond
+	w := &timestampWriter{w: &buf, elapsed: func() time.Duration { return d }}
+	for _, s := range []string{"a\nb", "c\n", "", "d\ne\n"} {
+		if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatal(n, err)
+		}
+		d += 62 * time.Second
+	}
+	want := "[00:03.241] a\n[00:03.241] bc\n[03:09.241] d\n[03:09.241] e\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	if got := formatElapsed(125*time.Minute + 1500*time.Millisecond); got != "[125:01.500] " {
+		t.Fatal(got)
+	}
+}
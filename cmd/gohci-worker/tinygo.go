@@ -0,0 +1,148 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"periph.io/x/gohci"
+)
+
+// tinyGoURL returns the URL of the TinyGo release for this host.
+func tinyGoURL(version, goos, goarch string) string {
+	return fmt.Sprintf("https://github.com/tinygo-org/tinygo/releases/download/v%s/tinygo%s.%s-%s.tar.gz", version, version, goos, goarch)
+}
+
+// installTinyGo installs the TinyGo release in wd/tinygo/<version> unless
+// already done and returns its bin directory.
+func installTinyGo(wd, version string) (string, error) {
+	if !isSubset(version, "0123456789.") {
+		return "", fmt.Errorf("invalid tinygoversion %q", version)
+	}
+	if runtime.GOOS == "windows" {
+		return "", errors.New("tinygoversion is not supported on windows")
+	}
+	dst := filepath.Join(wd, "tinygo", version)
+	bin := filepath.Join(dst, "tinygo", "bin")
+	if _, err := os.Stat(filepath.Join(bin, "tinygo")); err == nil {
+		return bin, nil
+	}
+	u := tinyGoURL(version, runtime.GOOS, runtime.GOARCH)
+	logMain.Info("installing tinygo", "url", u)
+	/* #nosec G107 */
+	resp, err := http.Get(u)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch %s: %s", u, resp.Status)
+	}
+	// Extract in a temporary directory first so an interrupted install is
+	// never used.
+	tmp := dst + ".tmp"
+	if err = os.RemoveAll(tmp); err != nil {
+		return "", err
+	}
+	if err = untar(resp.Body, tmp); err != nil {
+		_ = os.RemoveAll(tmp)
+		return "", err
+	}
+	if err = os.RemoveAll(dst); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp, dst); err != nil {
+		return "", err
+	}
+	return bin, nil
+}
+
+// untar extracts the gzipped tarball into dst.
+func untar(r io.Reader, dst string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	t := tar.NewReader(gz)
+	for {
+		h, err := t.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(dst, filepath.FromSlash(h.Name))
+		if p != dst && !strings.HasPrefix(p, dst+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %q in archive", h.Name)
+		}
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(p, 0o755)
+		case tar.TypeReg:
+			err = writeFileFrom(p, t, os.FileMode(h.Mode)&0o755)
+		case tar.TypeSymlink:
+			if err = os.MkdirAll(filepath.Dir(p), 0o755); err == nil {
+				err = os.Symlink(h.Linkname, p)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func writeFileFrom(p string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	/* #nosec G304 */
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// tinyGoCmd returns the tinygo command for the check.
+func tinyGoCmd(t *gohci.TinyGo) ([]string, error) {
+	if t.Target == "" {
+		return nil, errors.New("tinygo: target is required")
+	}
+	pkg := t.Package
+	if pkg == "" {
+		pkg = "."
+	}
+	cmd := []string{"tinygo", t.Command, "-target=" + t.Target}
+	switch t.Command {
+	case "build":
+		out := t.Output
+		if out == "" {
+			out = "firmware.hex"
+		}
+		cmd = append(cmd, "-o", out)
+	case "flash":
+		if t.Port != "" {
+			cmd = append(cmd, "-port="+t.Port)
+		}
+	case "test":
+	default:
+		return nil, fmt.Errorf("tinygo: unknown command %q; use build, flash or test", t.Command)
+	}
+	return append(cmd, pkg), nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestTinyGoURL(t *testing.T) {
+	want := "https://github.com/tinygo-org/tinygo/releases/download/v0.33.0/tinygo0.33.0.linux-arm64.tar.gz"
+	if got := tinyGoURL("0.33.0", "linux", "arm64"); got != want {
+		t.Fatal(got)
+	}
+}
+
+func TestTinyGoCmd(t *testing.T) {
+	data := []struct {
+		t    gohci.TinyGo
+		want []string
+	}{
+		{gohci.TinyGo{Command: "build", Target: "pico"}, []string{"tinygo", "build", "-target=pico", "-o", "firmware.hex", "."}},
+		{gohci.TinyGo{Command: "flash", Target: "pico", Port: "/dev/ttyACM0", Package: "./cmd/blink"}, []string{"tinygo", "flash", "-target=pico", "-port=/dev/ttyACM0", "./cmd/blink"}},
+		{gohci.TinyGo{Command: "test", Target: "wasi"}, []string{"tinygo", "test", "-target=wasi", "."}},
+	}
+	for i, l := range data {
+		got, err := checkCmd(&gohci.Check{TinyGo: &l.t})
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, l.want) {
+			t.Fatalf("#%d: %q != %q", i, got, l.want)
+		}
+	}
+	for i, l := range []gohci.TinyGo{{Command: "build"}, {Command: "run", Target: "pico"}} {
+		if _, err := tinyGoCmd(&l); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
+
+func TestUntar(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range []struct {
+		name    string
+		content string
+	}{{"tinygo/bin/tinygo", "#!/bin/sh\n"}, {"tinygo/README.md", "hi"}} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(t.TempDir(), "out")
+	if err := untar(&buf, dst); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dst, "tinygo", "README.md")); err != nil || string(b) != "hi" {
+		t.Fatal(string(b), err)
+	}
+}
+
+func TestUntarEscape(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0o644, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	if err := untar(&buf, filepath.Join(t.TempDir(), "out")); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// tokenRotator is an http.RoundTripper that authenticates with one of
+// multiple OAuth2 tokens, failing over to the next one when the current one is
+// revoked or rate limited.
+//
+// This way a single expired token doesn't take down a whole fleet of workers.
+type tokenRotator struct {
+	base   http.RoundTripper
+	tokens []string
+	scopes []string // Required scopes, verified by verifyTokens.
+
+	mu  sync.Mutex
+	cur int
+}
+
+// newTokenRotator returns a tokenRotator for the tokens in c.
+func newTokenRotator(c *gohci.WorkerConfig) *tokenRotator {
+	scopes := requiredScopes
+	if c.GistToken != "" {
+		scopes = []string{"repo:status"}
+	}
+	return &tokenRotator{base: http.DefaultTransport, tokens: configTokens(c), scopes: scopes}
+}
+
+// newGistRotator returns a tokenRotator for c.GistToken, only used for the
+// gists.
+func newGistRotator(c *gohci.WorkerConfig) *tokenRotator {
+	return &tokenRotator{base: http.DefaultTransport, tokens: []string{c.GistToken}, scopes: []string{"gist"}}
+}
+
+// configTokens returns Oauth2AccessToken followed by Oauth2AccessTokens,
+// skipping the placeholder written in the default config.
+func configTokens(c *gohci.WorkerConfig) []string {
+	var out []string
+	if c.Oauth2AccessToken != "" && !strings.HasPrefix(c.Oauth2AccessToken, "Get one at ") {
+		out = append(out, c.Oauth2AccessToken)
+	}
+	for _, t := range c.Oauth2AccessTokens {
+		if t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// current returns the index and value of the token in use.
+func (t *tokenRotator) current() (int, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.tokens) == 0 {
+		return 0, ""
+	}
+	return t.cur, t.tokens[t.cur]
+}
+
+// rotate switches to the token following i, unless another request already
+// did.
+func (t *tokenRotator) rotate(i int, reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cur == i {
+		t.cur = (i + 1) % len(t.tokens)
+		logMain.Warn("rotating OAuth2 token", "from", i, "to", t.cur, "reason", reason)
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenRotator) RoundTrip(r *http.Request) (*http.Response, error) {
+	for tries := 0; ; tries++ {
+		i, tok := t.current()
+		r2 := r.Clone(r.Context())
+		if tok != "" {
+			r2.Header.Set("Authorization", "Bearer "+tok)
+		}
+		if tries != 0 && r.Body != nil {
+			b, err := r.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r2.Body = b
+		}
+		resp, err := t.base.RoundTrip(r2)
+		if err != nil {
+			return resp, err
+		}
+		reason := rotateReason(resp)
+		// Only retry when the body can be sent again and there's another token
+		// to try.
+		if reason == "" || tries+1 >= len(t.tokens) || (r.Body != nil && r.GetBody == nil) {
+			return resp, nil
+		}
+		_ = resp.Body.Close()
+		t.rotate(i, reason)
+	}
+}
+
+// rotateReason returns why the token should not be used anymore, or "" if
+// the response is not about the token.
+func rotateReason(resp *http.Response) string {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "unauthorized"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "rate limited"
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return "rate limited"
+	default:
+		return ""
+	}
+}
+
+// requiredScopes are the OAuth2 scopes needed to create gists and update
+// commit statuses.
+var requiredScopes = []string{"gist", "repo:status"}
+
+// verifyTokens verifies at startup that the tokens are valid and have the
+// needed scopes, so a misconfiguration is reported right away instead of as
+// cryptic failures at job time.
+//
+// It returns an error if a token is missing a scope or if no token is valid.
+// It only warns when GitHub can't be reached, or for tokens with more scopes
+// than needed.
+func (t *tokenRotator) verifyTokens(ctx context.Context) error {
+	if len(t.tokens) == 0 {
+		return errors.New("no OAuth2 token configured; set oauth2accesstoken in gohci.yml")
+	}
+	valid, invalid := 0, 0
+	for i, tok := range t.tokens {
+		client := github.NewClient(&http.Client{Transport: &tokenRotator{base: t.base, tokens: []string{tok}}})
+		u, resp, err := client.Users.Get(ctx, "")
+		if err != nil {
+			if resp == nil {
+				logMain.Warn("failed to verify OAuth2 token", "token", i, "err", err)
+				continue
+			}
+			logMain.Error("invalid OAuth2 token", "token", i, "err", err)
+			invalid++
+			continue
+		}
+		valid++
+		h := resp.Header.Get("X-OAuth-Scopes")
+		if _, ok := resp.Header["X-Oauth-Scopes"]; !ok {
+			// Fine-grained tokens don't report their permissions.
+			logMain.Info("verified OAuth2 token; scopes can't be verified", "token", i, "user", u.GetLogin())
+			continue
+		}
+		missing, extra := checkScopes(h, t.scopes)
+		if len(missing) != 0 {
+			return fmt.Errorf("OAuth2 token %d for %s is missing scopes %s; it has %q", i, u.GetLogin(), strings.Join(missing, ", "), h)
+		}
+		if len(extra) != 0 {
+			// Reactions and team membership need more, so it's not fatal.
+			logMain.Warn("OAuth2 token has more scopes than needed", "token", i, "user", u.GetLogin(), "extra", extra)
+		}
+		logMain.Info("verified OAuth2 token", "token", i, "user", u.GetLogin(), "scopes", h)
+	}
+	if valid == 0 && invalid != 0 {
+		return errors.New("no valid OAuth2 token")
+	}
+	i, _ := t.current()
+	logMain.Info("using OAuth2 token", "token", i, "tokens", len(t.tokens))
+	return nil
+}
+
+// checkScopes returns the required scopes missing and the unneeded ones in the
+// X-OAuth-Scopes header value h.
+func checkScopes(h string, required []string) ([]string, []string) {
+	has := map[string]bool{}
+	for _, s := range strings.Split(h, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			has[s] = true
+		}
+	}
+	var missing, extra []string
+	needed := map[string]bool{}
+	for _, s := range required {
+		needed[s] = true
+		// "repo" includes "repo:status".
+		if !has[s] && !(s == "repo:status" && has["repo"]) {
+			missing = append(missing, s)
+		}
+	}
+	for s := range has {
+		if !needed[s] {
+			extra = append(extra, s)
+		}
+	}
+	sort.Strings(extra)
+	return missing, extra
+}
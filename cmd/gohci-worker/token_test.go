@@ -0,0 +1,76 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"periph.io/x/gohci"
+)
+
+func TestTokenRotator(t *testing.T) {
+	var seen []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		a := r.Header.Get("Authorization")
+		seen = append(seen, a+" "+string(b))
+		if a != "Bearer c" {
+			http.Error(w, "Bad credentials", http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	c := &gohci.WorkerConfig{Oauth2AccessToken: "a", Oauth2AccessTokens: []string{"b", "c"}}
+	r := newTokenRotator(c)
+	client := &http.Client{Transport: r}
+	resp, err := client.Post(ts.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(resp.Status)
+	}
+	expected := []string{"Bearer a body", "Bearer b body", "Bearer c body"}
+	if strings.Join(seen, ",") != strings.Join(expected, ",") {
+		t.Fatalf("%q != %q", seen, expected)
+	}
+	if i, _ := r.current(); i != 2 {
+		t.Fatalf("current token %d", i)
+	}
+}
+
+func TestConfigTokens(t *testing.T) {
+	c := &gohci.WorkerConfig{Oauth2AccessToken: "Get one at https://github.com/settings/tokens", Oauth2AccessTokens: []string{"", "b"}}
+	if got := configTokens(c); len(got) != 1 || got[0] != "b" {
+		t.Fatalf("%q", got)
+	}
+}
+
+func TestCheckScopes(t *testing.T) {
+	data := []struct {
+		in             string
+		missing, extra string
+	}{
+		{"gist, repo:status", "", ""},
+		{"repo, gist", "", "repo"},
+		{"gist", "repo:status", ""},
+		{"", "gist,repo:status", ""},
+		{"read:org, gist, repo:status, public_repo", "", "public_repo,read:org"},
+	}
+	for _, l := range data {
+		missing, extra := checkScopes(l.in, requiredScopes)
+		if strings.Join(missing, ",") != l.missing || strings.Join(extra, ",") != l.extra {
+			t.Fatalf("checkScopes(%q) = %q, %q", l.in, missing, extra)
+		}
+	}
+	// With a separate gist token, the gist scope is unneeded.
+	if missing, extra := checkScopes("gist, repo:status", []string{"repo:status"}); len(missing) != 0 || strings.Join(extra, ",") != "gist" {
+		t.Fatal(missing, extra)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reGoVersion matches a Go version like "1.22", "1.22.3" or "1.23rc1".
+var reGoVersion = regexp.MustCompile(`^1\.(\d+)(\.\d+|rc\d+)?$`)
+
+// goModToolchain returns the Go toolchain requested by the content of a
+// go.mod, e.g. "go1.22.3", or "" if none can be selected.
+//
+// The toolchain line has precedence over the go directive. Toolchains before
+// go1.21 can't be downloaded by the go command, so they are ignored.
+func goModToolchain(gomod string) string {
+	goLine := ""
+	toolchain := ""
+	for _, l := range strings.Split(gomod, "\n") {
+		if i := strings.Index(l, "//"); i != -1 {
+			l = l[:i]
+		}
+		f := strings.Fields(l)
+		if len(f) != 2 {
+			continue
+		}
+		switch f[0] {
+		case "go":
+			goLine = f[1]
+		case "toolchain":
+			toolchain = f[1]
+		}
+	}
+	v := goLine
+	if toolchain != "" && toolchain != "default" {
+		v = strings.TrimPrefix(toolchain, "go")
+	}
+	m := reGoVersion.FindStringSubmatch(v)
+	if m == nil {
+		return ""
+	}
+	if minor, err := strconv.Atoi(m[1]); err != nil || minor < 21 {
+		return ""
+	}
+	if m[2] == "" {
+		// "go 1.22" means "go1.22.0" since go1.21.
+		v += ".0"
+	}
+	return "go" + v
+}
@@ -0,0 +1,28 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import "testing"
+
+func TestGoModToolchain(t *testing.T) {
+	data := []struct {
+		in   string
+		want string
+	}{
+		{"module example.com/m\n\ngo 1.22.3\n", "go1.22.3"},
+		{"module example.com/m\n\ngo 1.22\n", "go1.22.0"},
+		{"module example.com/m\n\ngo 1.21\n\ntoolchain go1.23.1\n", "go1.23.1"},
+		{"module example.com/m\n\ngo 1.22.0 // comment\ntoolchain default\n", "go1.22.0"},
+		{"module example.com/m\n\ngo 1.23rc1\n", "go1.23rc1"},
+		{"module example.com/m\n\ngo 1.16\n", ""},
+		{"module example.com/m\n", ""},
+		{"module example.com/m\n\ngo 1.22;rm\n", ""},
+	}
+	for i, l := range data {
+		if got := goModToolchain(l.in); got != l.want {
+			t.Fatalf("#%d: %q != %q", i, got, l.want)
+		}
+	}
+}
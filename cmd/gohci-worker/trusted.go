@@ -0,0 +1,51 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"periph.io/x/gohci/internal/valid"
+)
+
+// baseConfig fetches the .gohci.yml of the PR's base branch in the job's
+// directory and returns its path.
+//
+// It returns "" when the base branch has no .gohci.yml.
+func (j *jobRequest) baseConfig() (string, error) {
+	if !valid.Ref(j.baseRef) {
+		return "", fmt.Errorf("invalid base branch %q", j.baseRef)
+	}
+	// The output of run() is decorated, so call git directly.
+	git := func(args ...string) (string, error) {
+		/* #nosec G204 */
+		c := exec.Command("git", args...)
+		c.Dir = filepath.Join(j.gopath, "src", j.getPath())
+		c.Env = j.env
+		b, err := c.Output()
+		if ee := (*exec.ExitError)(nil); errors.As(err, &ee) {
+			err = fmt.Errorf("git %s: %w\n%s", args[0], err, ee.Stderr)
+		}
+		return string(b), err
+	}
+	// FETCH_HEAD is not used after the checkout.
+	if _, err := git("fetch", "--quiet", "--depth", "1", "origin", "refs/heads/"+j.baseRef); err != nil {
+		return "", err
+	}
+	if out, err := git("ls-tree", "--name-only", "FETCH_HEAD", ".gohci.yml"); err != nil || strings.TrimSpace(out) == "" {
+		return "", err
+	}
+	b, err := git("show", "FETCH_HEAD:.gohci.yml")
+	if err != nil {
+		return "", err
+	}
+	f := filepath.Join(j.gopath, "base.gohci.yml")
+	return f, os.WriteFile(f, []byte(b), 0o600)
+}
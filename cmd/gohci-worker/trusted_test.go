@@ -0,0 +1,134 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+func TestParseConfigTrusted(t *testing.T) {
+	origin, _ := newTestOrigin(t)
+	git := func(dir string, args ...string) string {
+		c := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=a", "-c", "user.email=a@a"}, args...)...)
+		out, err := c.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	base := git(origin, "rev-parse", "--abbrev-ref", "HEAD")
+	j := &jobRequest{jobSpec: jobSpec{org: "periph", repo: "gohci"}, gopath: t.TempDir(), log: slog.Default()}
+	dir := filepath.Join(j.gopath, "src", j.getPath())
+	git(j.gopath, "clone", "--quiet", "file://"+origin, dir)
+
+	// The PR's .gohci.yml.
+	evil := "version: 1\nworkers:\n- checks:\n  - cmd: [rm, -rf, /]\n"
+	if err := os.WriteFile(filepath.Join(dir, ".gohci.yml"), []byte(evil), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if chks, _, _ := j.parseConfig("w"); len(chks) != 1 || chks[0].Cmd[0] != "rm" {
+		t.Fatal(chks)
+	}
+
+	// The base branch doesn't have a .gohci.yml yet.
+	j.baseRef = base
+	if chks, note, _ := j.parseConfig("w"); len(chks) != 1 || chks[0].Cmd[0] != "go" || !strings.Contains(note, base) {
+		t.Fatal(chks, note)
+	}
+
+	good := "version: 1\nworkers:\n- checks:\n  - cmd: [go, vet, ./...]\n"
+	if err := os.WriteFile(filepath.Join(origin, ".gohci.yml"), []byte(good), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	git(origin, "add", ".gohci.yml")
+	git(origin, "commit", "--quiet", "-m", "config")
+	if chks, _, _ := j.parseConfig("w"); len(chks) != 1 || chks[0].Cmd[1] != "vet" {
+		t.Fatal(chks)
+	}
+
+	j.baseRef = "missing"
+	if chks, note, _ := j.parseConfig("w"); len(chks) != 1 || chks[0].Cmd[0] != "go" || !strings.HasPrefix(note, "Failed") {
+		t.Fatal(chks, note)
+	}
+}
+
+func TestEnqueueCheckTrustedPRError(t *testing.T) {
+	var reqs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		reqs = append(reqs, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(b)))
+		if r.URL.Path == "/repos/o/r/pulls/1" {
+			http.Error(w, "{}", http.StatusBadGateway)
+			return
+		}
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{
+		name:   "w",
+		ctx:    context.Background(),
+		client: client,
+		gists:  client,
+		wd:     t.TempDir(),
+		c:      &gohci.WorkerConfig{TrustedConfig: true, AllowedRepos: []string{"o/r"}},
+	}
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	w.enqueueCheck(jobSpec{org: "o", repo: "r", commitHash: sha, pullID: 1})
+	// The job is not silently dropped.
+	want := []string{
+		"GET /repos/o/r/pulls/1",
+		`POST /repos/o/r/statuses/` + sha + ` {"state":"error","description":"Failed to get the PR's base branch","context":"w"}`,
+	}
+	if strings.Join(reqs, "\n") != strings.Join(want, "\n") {
+		t.Fatal(strings.Join(reqs, "\n"))
+	}
+}
+
+func TestEnqueueCheckReaction(t *testing.T) {
+	var reqs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs = append(reqs, r.Method+" "+r.URL.Path)
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	w := &workerQueue{
+		name:   "w",
+		ctx:    context.Background(),
+		client: client,
+		gists:  client,
+		wd:     t.TempDir(),
+		c:      &gohci.WorkerConfig{AllowedRepos: []string{"o/r"}},
+	}
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	c := comment{"issue", 42}
+	// A repository not allowed doesn't get a +1.
+	w.enqueueCheck(jobSpec{org: "o", repo: "other", commitHash: sha, comment: c})
+	if len(reqs) != 0 {
+		t.Fatal(reqs)
+	}
+	// Neither does a paused worker.
+	w.paused.Store(true)
+	w.enqueueCheck(jobSpec{org: "o", repo: "r", commitHash: sha, comment: c})
+	if want := []string{"POST /repos/o/r/statuses/" + sha}; strings.Join(reqs, "\n") != strings.Join(want, "\n") {
+		t.Fatal(reqs)
+	}
+}
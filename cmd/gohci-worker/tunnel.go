@@ -0,0 +1,145 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// hookEvents are the events gohci-worker handles.
+var hookEvents = []string{"commit_comment", "issue_comment", "pull_request", "pull_request_review_comment", "push", "repository_dispatch", "merge_group"}
+
+// tunnelURLFile records the URL of the last tunnel in the working directory,
+// to recognize the webhooks pointing to this worker after a restart.
+const tunnelURLFile = "tunnel.txt"
+
+// startTunnel starts the tunnel process specified in the config and returns
+// it along the public URL of the worker.
+//
+// The token is passed via the environment, so it doesn't show up in the
+// process list.
+func startTunnel(c *gohci.WorkerConfig) (*exec.Cmd, string, error) {
+	var cmd *exec.Cmd
+	switch c.Tunnel {
+	case "cloudflared":
+		// The hostname of a named tunnel is configured on Cloudflare's side.
+		if c.TunnelURL == "" {
+			return nil, "", errors.New("tunnelurl is required with cloudflared")
+		}
+		/* #nosec G204 */
+		cmd = exec.Command("cloudflared", "tunnel", "--no-autoupdate", "run")
+		cmd.Env = append(os.Environ(), "TUNNEL_TOKEN="+c.TunnelToken)
+	case "ngrok":
+		/* #nosec G204 */
+		cmd = exec.Command("ngrok", "http", strconv.Itoa(c.Port), "--log", "stdout")
+		if c.TunnelToken != "" {
+			cmd.Env = append(os.Environ(), "NGROK_AUTHTOKEN="+c.TunnelToken)
+		}
+		if c.TunnelURL != "" {
+			// A reserved domain.
+			cmd.Args = append(cmd.Args, "--url", c.TunnelURL)
+		}
+	default:
+		return nil, "", fmt.Errorf("unknown tunnel %q", c.Tunnel)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, "", fmt.Errorf("failed to start %s: %w", c.Tunnel, err)
+	}
+	u := c.TunnelURL
+	if c.Tunnel == "ngrok" && u == "" {
+		var err error
+		if u, err = ngrokURL(); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return nil, "", err
+		}
+	}
+	if !strings.Contains(u, "://") {
+		u = "https://" + u
+	}
+	logServer.Info("tunnel started", "tunnel", c.Tunnel, "url", u)
+	return cmd, u, nil
+}
+
+// ngrokURL returns the public URL assigned by ngrok, via its local API.
+func ngrokURL() (string, error) {
+	var err error
+	for i := 0; i < 30; i++ {
+		time.Sleep(time.Second)
+		var resp *http.Response
+		if resp, err = http.Get("http://127.0.0.1:4040/api/tunnels"); err != nil {
+			continue
+		}
+		var t struct {
+			Tunnels []struct {
+				PublicURL string `json:"public_url"`
+			}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&t)
+		_ = resp.Body.Close()
+		for _, x := range t.Tunnels {
+			if strings.HasPrefix(x.PublicURL, "https://") {
+				return x.PublicURL, nil
+			}
+		}
+	}
+	if err == nil {
+		err = errors.New("no tunnel reported")
+	}
+	return "", fmt.Errorf("failed to get the ngrok URL: %w", err)
+}
+
+// isTunnelHost returns true if host is the one of tunnelURL.
+func isTunnelHost(host, tunnelURL string) bool {
+	u, err := url.Parse(tunnelURL)
+	return err == nil && tunnelURL != "" && strings.EqualFold(u.Hostname(), host)
+}
+
+// previousTunnelURL returns the tunnel URL of the previous run, then records
+// hookURL for the next one. It is only done once per process.
+func (w *workerQueue) previousTunnelURL(hookURL string) string {
+	w.tunnelOnce.Do(func() {
+		p := filepath.Join(w.wd, tunnelURLFile)
+		/* #nosec G304 */
+		if b, err := os.ReadFile(p); err == nil {
+			w.prevTunnel = strings.TrimSpace(string(b))
+		}
+		if err := os.WriteFile(p, []byte(hookURL+"\n"), 0o600); err != nil {
+			logServer.Warn("failed to record the tunnel URL", "err", err)
+		}
+	})
+	return w.prevTunnel
+}
+
+// ensureWebhook implements worker.
+//
+// An existing webhook pointing to this worker's tunnel, either the current
+// one or the one of the previous run, is updated, keeping its query
+// arguments. The webhooks of other workers using the same tunnel service are
+// left alone. Otherwise a new one is created. This requires the
+// "admin:repo_hook" scope.
+func (w *workerQueue) ensureWebhook(org, repo, hookURL string) error {
+	prev := w.previousTunnelURL(hookURL)
+	match := func(u *url.URL) bool {
+		return isTunnelHost(u.Hostname(), hookURL) || isTunnelHost(u.Hostname(), prev)
+	}
+	_, action, err := upsertWebhook(w.ctx, w.client, "repos/"+org+"/"+repo, hookURL, w.c.WebHookSecret, match, false)
+	if err == nil && action != "unchanged" {
+		logServer.Info("webhook "+action, "repo", org+"/"+repo, "url", hookURL)
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"periph.io/x/gohci"
+)
+
+// nextWarm returns the next time after now at the "HH:MM" local time.
+func nextWarm(now time.Time, at string) (time.Time, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next, nil
+}
+
+// runWarmer warms the caches of WarmRepos every day at WarmAt until the
+// process exits.
+func runWarmer(c *gohci.WorkerConfig, w worker) {
+	for {
+		next, err := nextWarm(time.Now(), c.WarmAt)
+		if err != nil {
+			logMain.Error("invalid warmat", "err", err)
+			return
+		}
+		time.Sleep(time.Until(next))
+		w.warm()
+	}
+}
+
+// warm implements worker.
+func (w *workerQueue) warm() {
+	w.wg.Add(1)
+	defer w.wg.Done()
+	// Never run along a job, they share the same GOPATH.
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, r := range w.c.WarmRepos {
+		parts := strings.SplitN(r, "/", 2)
+		s := jobSpec{org: parts[0], repo: parts[1], useSSH: w.c.PollUseSSH}
+		if rem := findRemote(w.c, parts[0], parts[1]); rem != nil {
+			s = remoteSpec(rem, "", "")
+		}
+		w.warmRepo(s)
+	}
+}
+
+// warmRepo fetches the default branch of the repository and its Go modules,
+// which are kept in the repository's GOPATH for the next jobs.
+func (w *workerQueue) warmRepo(s jobSpec) {
+	start := time.Now()
+	j := newJobRequest(s, w.wd)
+	if j.useSSH && w.knownHosts != "" {
+		j.env = append(j.env, sshCommand(w.knownHosts))
+	}
+	j.moduleToken = w.c.ModuleToken
+	j.reuse = w.c.ReuseWorkspace
+	if w.c.Worktrees {
+		j.mirror = mirrorPath(filepath.Join(w.wd, "mirrors"), s)
+	} else if w.c.ReferenceDir != "" {
+		j.reference = mirrorPath(w.c.ReferenceDir, s)
+	}
+	j.env = append(j.env, goEnv(w.c)...)
+	// Fetch the remote's HEAD.
+	j.commitHash = "HEAD"
+	// cleanup() sends at most one result per call; they are discarded.
+	results := make(chan gistFile, 2)
+	j.cleanup("precleanup", results)
+	out, ok := j.checkout()
+	if ok {
+		// Applies goprivate and language.
+		j.parseConfig(w.name)
+		p := filepath.Join("src", j.getPath())
+		if _, err := os.Stat(filepath.Join(j.gopath, p, "go.mod")); err == nil {
+			var out2 string
+			out2, ok = j.run(p, nil, []string{"go", "mod", "download"}, true)
+			out += out2
+		}
+	}
+	j.cleanup("cleanup", results)
+	if !ok {
+		j.log.Warn("failed to warm the cache", "out", out)
+		return
+	}
+	j.log.Info("warmed the cache", "d", roundDuration(time.Since(start)))
+}
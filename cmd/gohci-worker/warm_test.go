@@ -0,0 +1,34 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextWarm(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	data := []struct {
+		at   string
+		want time.Time
+	}{
+		{"11:00", time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{"03:00", time.Date(2026, 3, 5, 3, 0, 0, 0, time.UTC)},
+		{"10:30", time.Date(2026, 3, 5, 10, 30, 0, 0, time.UTC)},
+	}
+	for i, l := range data {
+		got, err := nextWarm(now, l.at)
+		if err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+		if !got.Equal(l.want) {
+			t.Fatalf("#%d: %s != %s", i, got, l.want)
+		}
+	}
+	if _, err := nextWarm(now, "3am"); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+// webhookPingWait is how long to wait for the ping to be delivered.
+const webhookPingWait = 10 * time.Second
+
+// readAdminToken returns the admin token from $GOHCI_ADMIN_TOKEN or, when
+// unset, from stdin.
+func readAdminToken() (string, error) {
+	if token := os.Getenv("GOHCI_ADMIN_TOKEN"); token != "" {
+		return token, nil
+	}
+	fmt.Print("Admin token (used once, not stored): ")
+	l, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && l == "" {
+		return "", err
+	}
+	return strings.TrimSpace(l), nil
+}
+
+// registerWebhooks creates or updates the webhooks of the organizations in
+// AllowedOrgs and the other repositories in AllowedRepos and PollRepos to
+// point to hookURL with the worker's secret, then pings them.
+//
+// It needs an admin token, which is only used for this and never stored. It is
+// read from $GOHCI_ADMIN_TOKEN or from stdin.
+func registerWebhooks(c *gohci.WorkerConfig, hookURL string) error {
+	if u, err := url.Parse(hookURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("invalid -registerwebhooks %q; use the worker's public URL, e.g. \"https://ci.example.com/\"", hookURL)
+	}
+	token, err := readAdminToken()
+	if err != nil {
+		return err
+	}
+	var bases []string
+	orgs := map[string]bool{}
+	for _, o := range c.AllowedOrgs {
+		orgs[strings.ToLower(o)] = true
+		bases = append(bases, "orgs/"+o)
+	}
+	seen := map[string]bool{}
+	for _, r := range append(append([]string{}, c.AllowedRepos...), c.PollRepos...) {
+		parts := strings.SplitN(r, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid repository %q; use \"org/repo\"", r)
+		}
+		// The organization's webhook covers it.
+		if !orgs[strings.ToLower(parts[0])] && !seen[r] {
+			seen[r] = true
+			bases = append(bases, "repos/"+r)
+		}
+	}
+	if len(bases) == 0 {
+		return errors.New("no organization or repository in allowedorgs, allowedrepos or pollrepos")
+	}
+	client := github.NewClient(&http.Client{Transport: &tokenRotator{base: http.DefaultTransport, tokens: []string{token}}})
+	failed := 0
+	for _, b := range bases {
+		msg, err := registerWebhook(context.Background(), client, b, hookURL, c.WebHookSecret, webhookPingWait)
+		if err != nil {
+			fmt.Printf("%s: %v\n", b, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s: %s\n", b, msg)
+	}
+	if failed != 0 {
+		return fmt.Errorf("failed to register %d webhooks", failed)
+	}
+	return nil
+}
+
+// registerWebhook creates or updates the webhook of an organization or a
+// repository, base being "orgs/<org>" or "repos/<org>/<repo>", then pings it
+// and waits up to wait for the worker's response.
+//
+// An existing webhook with the same host and path is updated, keeping its
+// query arguments unless hookURL has some.
+func registerWebhook(ctx context.Context, client *github.Client, base, hookURL, secret string, wait time.Duration) (string, error) {
+	n, _ := url.Parse(hookURL)
+	match := func(u *url.URL) bool {
+		return strings.EqualFold(u.Host, n.Host) && u.Path == n.Path
+	}
+	h, action, err := upsertWebhook(ctx, client, base, hookURL, secret, match, true)
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("%s/hooks/%d", base, h.GetID())
+	// Only look at the deliveries of this ping; the hook may have older ones.
+	var last int64
+	old, err := pingDeliveries(ctx, client, path)
+	if err != nil {
+		return "", err
+	}
+	for _, d := range old {
+		if d.ID > last {
+			last = d.ID
+		}
+	}
+	req, err := client.NewRequest("POST", path+"/pings", nil)
+	if err != nil {
+		return "", err
+	}
+	if _, err = client.Do(ctx, req, nil); err != nil {
+		return "", fmt.Errorf("webhook %s but the ping failed: %w", action, err)
+	}
+	for start := time.Now(); ; time.Sleep(time.Second) {
+		deliveries, err := pingDeliveries(ctx, client, path)
+		if err != nil {
+			return "", err
+		}
+		for _, d := range deliveries {
+			if d.ID <= last {
+				continue
+			}
+			if d.StatusCode != http.StatusOK {
+				return "", fmt.Errorf("webhook %s but the worker replied to the ping with %d %s", action, d.StatusCode, d.Status)
+			}
+			return "webhook " + action + " and pinged", nil
+		}
+		if time.Since(start) > wait {
+			return "", fmt.Errorf("webhook %s but the ping wasn't delivered; is the worker running at %s?", action, hookURL)
+		}
+	}
+}
+
+// hookDelivery is a webhook delivery, as returned by the deliveries API.
+//
+// https://docs.github.com/en/rest/repos/webhooks#list-deliveries-for-a-repository-webhook
+type hookDelivery struct {
+	ID         int64  `json:"id"`
+	Event      string `json:"event"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+}
+
+// pingDeliveries returns the recent ping deliveries of the webhook at path.
+//
+// go-github doesn't support the deliveries API.
+func pingDeliveries(ctx context.Context, client *github.Client, path string) ([]hookDelivery, error) {
+	req, err := client.NewRequest("GET", path+"/deliveries?per_page=100", nil)
+	if err != nil {
+		return nil, err
+	}
+	var all []hookDelivery
+	if _, err = client.Do(ctx, req, &all); err != nil {
+		return nil, err
+	}
+	var out []hookDelivery
+	for _, d := range all {
+		if d.Event == "ping" {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// upsertWebhook creates or updates the webhook of an organization or a
+// repository, base being "orgs/<org>" or "repos/<org>/<repo>", to point to
+// hookURL with the secret.
+//
+// The first existing webhook whose URL matches is updated, keeping its query
+// arguments unless hookURL has some. It is left as is when it already points
+// to this URL, unless force is true. Returns the webhook and "created",
+// "updated" or "unchanged".
+//
+// The requests are done manually since the API is the same for both and
+// go-github's Hook doesn't support the top level name required for
+// organizations.
+func upsertWebhook(ctx context.Context, client *github.Client, base, hookURL, secret string, match func(u *url.URL) bool, force bool) (*github.Hook, string, error) {
+	var hooks []*github.Hook
+	req, err := client.NewRequest("GET", base+"/hooks?per_page=100", nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = client.Do(ctx, req, &hooks); err != nil {
+		return nil, "", err
+	}
+	n, err := url.Parse(hookURL)
+	if err != nil {
+		return nil, "", err
+	}
+	h := struct {
+		Name   string                 `json:"name,omitempty"`
+		Config map[string]interface{} `json:"config"`
+		Events []string               `json:"events"`
+		Active bool                   `json:"active"`
+	}{
+		Config: map[string]interface{}{"url": hookURL, "content_type": "json", "secret": secret},
+		Events: hookEvents,
+		Active: true,
+	}
+	for _, old := range hooks {
+		s, _ := old.Config["url"].(string)
+		u, err := url.Parse(s)
+		if err != nil || !match(u) {
+			continue
+		}
+		if n.RawQuery == "" {
+			// Keep altPath and superUsers.
+			k := *n
+			k.RawQuery = u.RawQuery
+			h.Config["url"] = k.String()
+		}
+		if !force && h.Config["url"] == s {
+			return old, "unchanged", nil
+		}
+		if req, err = client.NewRequest("PATCH", fmt.Sprintf("%s/hooks/%d", base, old.GetID()), &h); err != nil {
+			return nil, "", err
+		}
+		out := &github.Hook{}
+		_, err = client.Do(ctx, req, out)
+		return out, "updated", err
+	}
+	h.Name = "web"
+	if req, err = client.NewRequest("POST", base+"/hooks", &h); err != nil {
+		return nil, "", err
+	}
+	out := &github.Hook{}
+	_, err = client.Do(ctx, req, out)
+	return out, "created", err
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v31/github"
+	"periph.io/x/gohci"
+)
+
+func TestRegisterWebhook(t *testing.T) {
+	var writes []string
+	pinged := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/o/r/hooks":
+			_, _ = io.WriteString(w, `[{"id":1,"config":{"url":"https://other/"}},{"id":2,"config":{"url":"https://CI.example.com/?altPath=x"}}]`)
+		case r.Method == "GET" && r.URL.Path == "/orgs/o/hooks":
+			_, _ = io.WriteString(w, `[]`)
+		case r.Method == "GET" && r.URL.Path == "/repos/o/r/hooks/2/deliveries":
+			// The previous ping failed.
+			d := `{"id":5,"event":"ping","status":"Invalid HTTP Response: 500","status_code":500}`
+			if pinged[r.URL.Path] {
+				d = `{"id":7,"event":"push","status":"OK","status_code":200},{"id":6,"event":"ping","status":"OK","status_code":200},` + d
+			}
+			_, _ = io.WriteString(w, "["+d+"]")
+		case r.Method == "GET" && r.URL.Path == "/orgs/o/hooks/3/deliveries":
+			// The automatic ping on creation succeeded.
+			d := `{"id":9,"event":"ping","status":"OK","status_code":200}`
+			if pinged[r.URL.Path] {
+				d = `{"id":10,"event":"ping","status":"Invalid HTTP Response: 401","status_code":401},` + d
+			}
+			_, _ = io.WriteString(w, "["+d+"]")
+		default:
+			writes = append(writes, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(b)))
+			if strings.HasSuffix(r.URL.Path, "/pings") {
+				pinged[strings.TrimSuffix(r.URL.Path, "/pings")+"/deliveries"] = true
+				w.WriteHeader(http.StatusNoContent)
+			} else if r.URL.Path == "/orgs/o/hooks" {
+				_, _ = io.WriteString(w, `{"id":3}`)
+			} else {
+				_, _ = io.WriteString(w, `{"id":2}`)
+			}
+		}
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	ctx := context.Background()
+	if msg, err := registerWebhook(ctx, client, "repos/o/r", "https://ci.example.com/", "secret", time.Second); err != nil || msg != "webhook updated and pinged" {
+		t.Fatal(msg, err)
+	}
+	if _, err := registerWebhook(ctx, client, "orgs/o", "https://ci.example.com/", "secret", time.Second); err == nil || !strings.Contains(err.Error(), "webhook created but the worker replied to the ping with 401 Invalid HTTP Response: 401") {
+		t.Fatal(err)
+	}
+	events := `"events":["commit_comment","issue_comment","pull_request","pull_request_review_comment","push","repository_dispatch","merge_group"],"active":true}`
+	want := []string{
+		`PATCH /repos/o/r/hooks/2 {"config":{"content_type":"json","secret":"secret","url":"https://ci.example.com/?altPath=x"},` + events,
+		`POST /repos/o/r/hooks/2/pings`,
+		`POST /orgs/o/hooks {"name":"web","config":{"content_type":"json","secret":"secret","url":"https://ci.example.com/"},` + events,
+		`POST /orgs/o/hooks/3/pings`,
+	}
+	if strings.Join(writes, "\n") != strings.Join(want, "\n") {
+		t.Fatal(strings.Join(writes, "\n"))
+	}
+
+	// Without force, a webhook already pointing to the URL is left as is.
+	writes = nil
+	match := func(u *url.URL) bool { return u.Host == "CI.example.com" }
+	if h, action, err := upsertWebhook(ctx, client, "repos/o/r", "https://CI.example.com/?altPath=x", "secret", match, false); err != nil || action != "unchanged" || h.GetID() != 2 {
+		t.Fatal(action, err)
+	}
+	if len(writes) != 0 {
+		t.Fatal(writes)
+	}
+}
+
+func TestEnsureWebhook(t *testing.T) {
+	var writes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if r.Method == "GET" && r.URL.Path == "/repos/o/r/hooks" {
+			// Another worker's tunnel, then this worker's previous one.
+			_, _ = io.WriteString(w, `[{"id":1,"config":{"url":"https://other.ngrok-free.app/"}},{"id":2,"config":{"url":"https://old.ngrok-free.app/?altPath=x"}}]`)
+			return
+		}
+		writes = append(writes, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+string(b)))
+		_, _ = io.WriteString(w, `{"id":2}`)
+	}))
+	defer ts.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(ts.URL + "/")
+	wd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(wd, tunnelURLFile), []byte("https://old.ngrok-free.app/\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w := &workerQueue{ctx: context.Background(), client: client, wd: wd, c: &gohci.WorkerConfig{WebHookSecret: "secret"}}
+	if err := w.ensureWebhook("o", "r", "https://new.ngrok-free.app/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(writes) != 1 || !strings.HasPrefix(writes[0], `PATCH /repos/o/r/hooks/2 {"config":{"content_type":"json","secret":"secret","url":"https://new.ngrok-free.app/?altPath=x"}`) {
+		t.Fatal(writes)
+	}
+	if b, _ := os.ReadFile(filepath.Join(wd, tunnelURLFile)); string(b) != "https://new.ngrok-free.app/\n" {
+		t.Fatalf("%q", b)
+	}
+}
@@ -6,14 +6,18 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-github/v31/github"
-	"golang.org/x/oauth2"
 	"periph.io/x/gohci"
 )
 
@@ -22,84 +26,312 @@ type worker interface {
 	// enqueueCheck immediately add the status that the test run is pending and
 	// add the run in the queue. Ensures that the service doesn't restart until
 	// the task is done.
-	enqueueCheck(org, repo, altpath, commitHash string, useSSH bool, pullID int, blame []string)
+	enqueueCheck(s jobSpec)
 	// wait waits until all enqueued worker job requests are done.
 	wait()
+	// jobs returns the jobs in the history matching f, most recent first.
+	jobs(f jobFilter) []jobRecord
+	// job returns a job in the history.
+	job(id int64) (jobRecord, bool)
+	// defaultBranch returns the repository's default branch as reported by
+	// GitHub, or "" if it cannot be determined.
+	defaultBranch(org, repo string) string
+	// stream returns the live output of a job that is pending or running, nil
+	// otherwise.
+	stream(id int64) *logStream
+	// cancel cancels a job that is pending or running. Returns false if the
+	// job is not found.
+	cancel(id int64, reason string) bool
+	// cancelPR cancels the pending and running jobs of a PR. Returns the
+	// number of jobs canceled.
+	cancelPR(org, repo string, pullID int, reason string) int
+	// retry enqueues again the job in the history.
+	retry(id int64) error
+	// warm fetches the repositories in WarmRepos and their Go modules, so the
+	// next jobs don't have to.
+	warm()
+	// cleanupGists deletes the gists created by this worker that are beyond
+	// GistMaxAge or GistMaxPerRepo.
+	cleanupGists()
+	// setPaused sets the pause mode. While paused, new jobs are refused with a
+	// "Worker paused" status but the enqueued ones complete.
+	setPaused(paused bool)
+	// isPaused returns true if the worker is paused.
+	isPaused() bool
+	// load returns the number of pending and running jobs.
+	load() int
+	// selfTest exercises GitHub the way a job does, with a throwaway gist and a
+	// status on SelfTestRepo.
+	selfTest() []selfTestStep
+	// canWrite returns true if the user has write, maintain or admin
+	// permission on the repository.
+	canWrite(org, repo, user string) bool
+	// inTeam returns true if the user is an active member of the team
+	// "org/team".
+	inTeam(org, team, user string) bool
+	// react adds a reaction to a comment, e.g. "+1", "-1" or "rocket". It is
+	// best effort.
+	react(org, repo string, c comment, content string)
+	// heads returns the commits at the head of the default branch and the open
+	// PRs.
+	heads(org, repo string) ([]head, error)
+	// hasStatus returns true if the commit already has a status from this
+	// worker.
+	hasStatus(org, repo, sha string) (bool, error)
+	// ensureWebhook creates or updates the repository's webhook to point to
+	// hookURL.
+	ensureWebhook(org, repo, hookURL string) error
 }
 
 // workerQueue is the task queue server.
 type workerQueue struct {
 	name   string // Copy of config.Name
+	c      *gohci.WorkerConfig
 	ctx    context.Context
 	client *github.Client // Used to set commit status and create gists.
+	gists  *github.Client // Used for the gists; client unless GistToken is set.
+	blobs  blobStore      // Replaces the gists when a cloud storage is set.
 	wd     string
+	h      *jobHistory
+
+	knownHosts string // Path to the pinned SSH host keys, if any.
+	tinyGoBin  string // Path to the installed TinyGo, if any.
+
+	muBranches sync.Mutex
+	branches   map[string]string // Cache of the default branch per repository.
+
+	muPerms sync.Mutex
+	perms   map[string]permission // Cache of the users' permission per repository or team.
+
+	tunnelOnce sync.Once // Reads prevTunnel.
+	prevTunnel string    // Tunnel URL of the previous run.
+
+	muActive sync.Mutex
+	active   map[int64]*jobRequest // Pending and running jobs.
+
+	locks *lockRegistry // Named hardware resources used by the checks.
+	procs *procRegistry // Process groups of the running checks.
+
+	paused atomic.Bool // Set when new jobs are refused.
 
 	mu sync.Mutex     // Set when a check is running in runJobRequest()
 	wg sync.WaitGroup // Set for each pending task.
 }
 
-func newWorkerQueue(name, wd string, accessToken string) worker {
-	tc := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken}))
-	return &workerQueue{
-		name:   name,
-		ctx:    context.Background(),
-		client: github.NewClient(tc),
-		wd:     wd,
+// newWorkerQueue returns a worker after verifying the OAuth2 tokens, unless
+// verify is false.
+func newWorkerQueue(c *gohci.WorkerConfig, wd string, h *jobHistory, verify bool) (worker, error) {
+	knownHosts, err := writeKnownHosts(wd, c.SSHKnownHosts)
+	if err != nil {
+		return nil, err
+	}
+	tinyGoBin := ""
+	if c.TinyGoVersion != "" {
+		if tinyGoBin, err = installTinyGo(wd, c.TinyGoVersion); err != nil {
+			return nil, err
+		}
+	}
+	t := newTokenRotator(c)
+	w := &workerQueue{
+		name:       c.Name,
+		c:          c,
+		ctx:        context.Background(),
+		client:     github.NewClient(&http.Client{Transport: t}),
+		wd:         wd,
+		h:          h,
+		knownHosts: knownHosts,
+		tinyGoBin:  tinyGoBin,
+		branches:   map[string]string{},
+		perms:      map[string]permission{},
+		active:     map[int64]*jobRequest{},
+		locks:      newLockRegistry(),
+		procs:      newProcRegistry(filepath.Join(wd, "processes.txt")),
+	}
+	w.gists = w.client
+	if c.GistToken != "" {
+		g := newGistRotator(c)
+		w.gists = github.NewClient(&http.Client{Transport: g})
+		if verify {
+			if err := g.verifyTokens(w.ctx); err != nil {
+				return nil, fmt.Errorf("gisttoken: %w", err)
+			}
+		}
+	}
+	if c.GCSBucket != "" {
+		if w.blobs, err = newGCSClient(c.GCSBucket, c.GCSCredentials, c.GCSURLExpiry); err != nil {
+			return nil, err
+		}
+	} else if c.ExportTarget != "" {
+		w.blobs = &exportStore{method: c.ExportMethod, target: c.ExportTarget, baseURL: c.ExportURL, dir: filepath.Join(wd, "export")}
+	} else if c.AzureAccount != "" {
+		if w.blobs, err = newAzureClient(c.AzureAccount, c.AzureContainer, c.AzureKey, c.AzureURLExpiry); err != nil {
+			return nil, err
+		}
+	}
+	if verify {
+		if err := t.verifyTokens(w.ctx); err != nil {
+			return nil, err
+		}
 	}
+	return w, nil
 }
 
 // enqueueCheck implements worker.
-func (w *workerQueue) enqueueCheck(org, repo, altpath, commitHash string, useSSH bool, pullID int, blame []string) {
+func (w *workerQueue) enqueueCheck(s jobSpec) {
 	w.wg.Add(1)
 	defer w.wg.Done()
 
-	j := newJobRequest(org, repo, altpath, commitHash, useSSH, pullID, w.wd)
+	j := newJobRequest(s, w.wd)
+	if j.useSSH && w.knownHosts != "" {
+		j.env = append(j.env, sshCommand(w.knownHosts))
+	}
+	if !j.restricted {
+		j.moduleToken = w.c.ModuleToken
+		// Untrusted code must never leave anything behind for the next job.
+		j.reuse = w.c.ReuseWorkspace && j.localDir == ""
+		if w.c.Worktrees && j.localDir == "" {
+			j.mirror = mirrorPath(filepath.Join(w.wd, "mirrors"), s)
+		} else if w.c.ReferenceDir != "" && j.localDir == "" {
+			j.reference = mirrorPath(w.c.ReferenceDir, s)
+		}
+	} else {
+		j.allowedCommands = w.c.RestrictedCommands
+	}
+	j.env = append(j.env, goEnv(w.c)...)
+	if w.tinyGoBin != "" {
+		j.prependPath(w.tinyGoBin)
+	}
+	if p := findProject(w.c, s.org, s.repo); p != nil {
+		j.defaultChecks = p.Checks
+		j.checksMode = p.ChecksMode
+		j.signingKeys = p.SigningKeys
+	}
+	j.labels = append([]string{runtime.GOOS, runtime.GOARCH}, w.c.Labels...)
+	j.locks = w.locks
+	j.procs = w.procs
+	j.nice = w.c.Nice
+	j.memoryLimit = w.c.MemoryLimit
+	j.timestamps = w.c.Timestamps
+	j.ionice = w.c.IONice
+	// Plain git remotes are explicitly configured.
+	if j.remoteURL == "" && !isAllowedRepo(w.c, s.org, s.repo) {
+		j.log.Warn("refusing job for a repository not in allowedorgs or allowedrepos")
+		return
+	}
 	// Immediately fetch the issue head commit inside the webhook, since
 	// it's a race condition.
-	if commitHash == "" && !j.findCommitHash() {
-		log.Printf("- failed to get HEAD for issue #%d", pullID)
+	if j.commitHash == "" && !j.findCommitHash() {
+		j.log.Error("failed to get HEAD")
+		return
+	}
+	if w.paused.Load() {
+		j.log.Warn("refusing job; worker paused")
+		w.status(j, &github.RepoStatus{
+			State:       github.String("error"),
+			Description: github.String("Worker paused"),
+			Context:     &w.name,
+		})
 		return
 	}
-	log.Printf("- Enqueuing test for %s at %s", j.getID(), j.commitHash)
+	if w.c.TrustedConfig && j.pullID != 0 && !j.restricted && j.remoteURL == "" {
+		pr, _, err := w.client.PullRequests.Get(w.ctx, j.org, j.repo, j.pullID)
+		if err != nil {
+			// Never fall back to the PR's .gohci.yml. Make it visible on the PR
+			// so it can be retried.
+			j.log.Error("failed to get the PR's base branch", "err", err)
+			w.status(j, &github.RepoStatus{
+				State:       github.String("error"),
+				Description: github.String("Failed to get the PR's base branch"),
+				Context:     &w.name,
+			})
+			return
+		}
+		j.baseRef = pr.GetBase().GetRef()
+	}
+	j.env = append(j.env, j.builtinEnv(w.name)...)
+	j.meta = j.metadata()
+	// Only acknowledge the comment once the job is accepted.
+	w.react(j.org, j.repo, j.comment, "+1")
+	j.log.Info("enqueuing")
+	desc := fmt.Sprintf("%s for %s", w.name, j)
+	if j.restricted {
+		desc += " (restricted)"
+	}
 
 	// https://developer.github.com/v3/gists/#create-a-gist
 	gist := &github.Gist{
-		Description: github.String(fmt.Sprintf("%s for %s", w.name, j)),
+		Description: github.String(desc),
 		// It is accessible via the URL without authentication even if "private".
 		Public: github.Bool(false),
 		Files: map[github.GistFilename]github.GistFile{
-			"setup-0-metadata": {Content: github.String(j.metadata())},
+			"setup-0-metadata": {Content: github.String(j.meta)},
 		},
 	}
-	gist, _, err := w.client.Gists.Create(w.ctx, gist)
-	if err != nil {
-		// Don't bother running the tests. We could try setting a status but if the
-		// account can't create the gist, it is possible it can't create the
-		// status too. Need to look at the possibl failure modes and decide which
-		// are worth handling explicitly.
-		log.Printf("- Failed to create gist: %v", err)
-		return
-	}
-	log.Printf("- Gist at %s", *gist.HTMLURL)
 	// https://developer.github.com/v3/repos/statuses/#create-a-status
 	status := &github.RepoStatus{
 		State:       github.String("pending"),
 		Description: github.String("Checks pending"),
 		Context:     &w.name,
+	}
+	// The job ID is needed first, as it is part of the blobs' prefix.
+	j.id = w.h.add(&jobRecord{
+		Org:        j.org,
+		Repo:       j.repo,
+		Commit:     j.commitHash,
+		PullID:     j.pullID,
+		Started:    time.Now(),
+		State:      "pending",
+		Ref:        j.ref,
+		AltPath:    j.altPath,
+		UseSSH:     j.useSSH,
+		Restricted: j.restricted,
+		Local:      j.local,
+		Event:      j.event,
+	})
+	j.log = j.log.With("job_id", j.id)
+	if !j.local {
+		var err error
+		if w.blobs != nil {
+			gist, err = w.blobCreate(j, gist)
+		} else {
+			gist, _, err = w.gists.Gists.Create(w.ctx, gist)
+		}
+		if err != nil {
+			// Don't bother running the tests. We could try setting a status but if the
+			// account can't create the gist, it is possible it can't create the
+			// status too. Need to look at the possibl failure modes and decide which
+			// are worth handling explicitly.
+			j.log.Error("failed to create gist", "err", err)
+			w.h.update(j.id, func(r *jobRecord) { r.State = "error" })
+			return
+		}
+		j.log.Info("gist created", "url", gist.GetHTMLURL())
+		w.h.update(j.id, func(r *jobRecord) { r.GistURL = gist.GetHTMLURL() })
 		// Link the gist right away, so users can click and refresh.
-		TargetURL: gist.HTMLURL,
+		status.TargetURL = gist.HTMLURL
+		if !w.status(j, status) {
+			// Don't bother running the tests.
+			w.h.update(j.id, func(r *jobRecord) { r.State = "error" })
+			return
+		}
 	}
-	if !w.status(j, status) {
-		// Don't bother running the tests.
-		return
+	if j.local {
+		// The gist's initial files are written along the results.
+		for n, f := range gist.Files {
+			writeResult(w.wd, j, string(n), f.GetContent())
+		}
 	}
+	j.out = newLogStream()
+	w.muActive.Lock()
+	w.active[j.id] = j
+	w.muActive.Unlock()
 	// Enqueue and run.
 	// TODO(maruel): It should be a buffered channel so it stays FIFO and can
 	// deny when there's too many tasks enqueued.
 	w.wg.Add(1)
 	go func() {
 		defer w.wg.Done()
-		w.runJobRequest(j, gist, status, blame)
+		w.runJobRequest(j, newGistOutput(gist), status)
 	}()
 }
 
@@ -108,39 +340,294 @@ func (w *workerQueue) wait() {
 	w.wg.Wait()
 }
 
+// setPaused implements worker.
+func (w *workerQueue) setPaused(paused bool) {
+	w.paused.Store(paused)
+}
+
+// isPaused implements worker.
+func (w *workerQueue) isPaused() bool {
+	return w.paused.Load()
+}
+
+// load implements worker.
+func (w *workerQueue) load() int {
+	w.muActive.Lock()
+	defer w.muActive.Unlock()
+	return len(w.active)
+}
+
+// jobs implements worker.
+func (w *workerQueue) jobs(f jobFilter) []jobRecord {
+	return w.h.list(f)
+}
+
+// job implements worker.
+func (w *workerQueue) job(id int64) (jobRecord, bool) {
+	return w.h.get(id)
+}
+
+// defaultBranch implements worker.
+func (w *workerQueue) defaultBranch(org, repo string) string {
+	id := org + "/" + repo
+	w.muBranches.Lock()
+	defer w.muBranches.Unlock()
+	if b, ok := w.branches[id]; ok {
+		return b
+	}
+	r, _, err := w.client.Repositories.Get(w.ctx, org, repo)
+	if err != nil {
+		logJob.Error("failed to get default branch", "repo", id, "err", err)
+		return ""
+	}
+	w.branches[id] = r.GetDefaultBranch()
+	return w.branches[id]
+}
+
+// permission is a cached permission lookup.
+type permission struct {
+	write   bool
+	expires time.Time
+}
+
+// canWrite implements worker.
+//
+// The result is cached for a few minutes, so a burst of events doesn't
+// trigger a burst of API calls, yet a revoked access is eventually honored.
+func (w *workerQueue) canWrite(org, repo, user string) bool {
+	id := org + "/" + repo + "/" + user
+	w.muPerms.Lock()
+	defer w.muPerms.Unlock()
+	if p, ok := w.perms[id]; ok && time.Now().Before(p.expires) {
+		return p.write
+	}
+	l, _, err := w.client.Repositories.GetPermissionLevel(w.ctx, org, repo, user)
+	if err != nil {
+		logJob.Error("failed to get permission", "repo", org+"/"+repo, "user", user, "err", err)
+		return false
+	}
+	// "maintain" is reported as "write" by this API.
+	p := l.GetPermission()
+	w.perms[id] = permission{write: p == "admin" || p == "write", expires: time.Now().Add(5 * time.Minute)}
+	return w.perms[id].write
+}
+
+// inTeam implements worker.
+//
+// This requires the "read:org" scope. The result is cached like canWrite.
+func (w *workerQueue) inTeam(org, team, user string) bool {
+	id := "team:" + org + "/" + team + "/" + user
+	w.muPerms.Lock()
+	defer w.muPerms.Unlock()
+	if p, ok := w.perms[id]; ok && time.Now().Before(p.expires) {
+		return p.write
+	}
+	m, resp, err := w.client.Teams.GetTeamMembershipBySlug(w.ctx, org, team, user)
+	if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+		logJob.Error("failed to get team membership", "team", org+"/"+team, "user", user, "err", err)
+		return false
+	}
+	// A 404 means the user is not a member.
+	w.perms[id] = permission{write: err == nil && m.GetState() == "active", expires: time.Now().Add(5 * time.Minute)}
+	return w.perms[id].write
+}
+
+// react implements worker.
+func (w *workerQueue) react(org, repo string, c comment, content string) {
+	var err error
+	switch c.kind {
+	case "commit":
+		_, _, err = w.client.Reactions.CreateCommentReaction(w.ctx, org, repo, c.id, content)
+	case "issue":
+		_, _, err = w.client.Reactions.CreateIssueCommentReaction(w.ctx, org, repo, c.id, content)
+	case "review":
+		_, _, err = w.client.Reactions.CreatePullRequestCommentReaction(w.ctx, org, repo, c.id, content)
+	default:
+		return
+	}
+	if err != nil {
+		// This requires the "public_repo" or "repo" scope, which may
+		// intentionally not be granted.
+		logJob.Warn("failed to add reaction", "repo", org+"/"+repo, "comment", c.id, "content", content, "err", err)
+	}
+}
+
+// stream implements worker.
+func (w *workerQueue) stream(id int64) *logStream {
+	w.muActive.Lock()
+	defer w.muActive.Unlock()
+	if j := w.active[id]; j != nil {
+		return j.out
+	}
+	return nil
+}
+
+// cancel implements worker.
+func (w *workerQueue) cancel(id int64, reason string) bool {
+	w.muActive.Lock()
+	j := w.active[id]
+	w.muActive.Unlock()
+	if j == nil {
+		return false
+	}
+	j.log.Info("canceling", "reason", reason)
+	j.cancel(errors.New(reason))
+	return true
+}
+
+// cancelPR implements worker.
+func (w *workerQueue) cancelPR(org, repo string, pullID int, reason string) int {
+	w.muActive.Lock()
+	var jobs []*jobRequest
+	for _, j := range w.active {
+		if j.org == org && j.repo == repo && j.pullID == pullID {
+			jobs = append(jobs, j)
+		}
+	}
+	w.muActive.Unlock()
+	for _, j := range jobs {
+		j.log.Info("canceling", "reason", reason)
+		j.cancel(errors.New(reason))
+	}
+	return len(jobs)
+}
+
+// retry implements worker.
+//
+// The job is run again at the same commit, with the same settings.
+func (w *workerQueue) retry(id int64) error {
+	r, ok := w.h.get(id)
+	if !ok {
+		return errors.New("job not found")
+	}
+	s := jobSpec{org: r.Org, repo: r.Repo, altPath: r.AltPath, commitHash: r.Commit, ref: r.Ref, pullID: r.PullID, useSSH: r.UseSSH, restricted: r.Restricted, event: r.Event}
+	if r.Local {
+		rem := findRemote(w.c, r.Org, r.Repo)
+		if rem == nil {
+			return errors.New("can't retry a local job")
+		}
+		s = remoteSpec(rem, r.Ref, r.Commit)
+		s.event = r.Event
+	}
+	w.enqueueCheck(s)
+	return nil
+}
+
 // runJobRequest runs the check for the repository hosted on github at the
 // specified commit.
 //
 // It will use the ssh protocol if "useSSH" is set, https otherwise.
 // "status" is the github status to keep updating as progress is made.
 //
-// TODO(maruel): If "blame" is not empty, an issue is created on failure.
-func (w *workerQueue) runJobRequest(j *jobRequest, gist *github.Gist, status *github.RepoStatus, blame []string) {
+// If "j.blame" is not empty and the project opted in, an issue is created on
+// failure.
+func (w *workerQueue) runJobRequest(j *jobRequest, gist *gistOutput, status *github.RepoStatus) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	defer j.cancel(nil)
 
-	log.Printf("- Running test for %s at %s", j.getID(), j.commitHash)
-	failed := w.runJobRequestInner(j, gist, status)
+	j.log.Info("running")
+	start := time.Now()
+	if j.ctx.Err() != nil {
+		// Canceled while pending.
+		w.cancelJobRequest(j, gist, status)
+		return
+	}
+	if !j.headExists() {
+		// The PR was closed or force-pushed while the job was queued. There's
+		// nothing to test anymore, so don't report a red failure.
+		w.skipJobRequest(j, gist, status, "head no longer exists")
+		return
+	}
+	w.h.update(j.id, func(r *jobRecord) {
+		r.State = "running"
+	})
+	w.notify(j.id, "started")
+	w.react(j.org, j.repo, j.comment, "rocket")
+	failed, skip := w.runJobRequestInner(j, gist, status)
+	if j.ctx.Err() != nil {
+		w.cancelJobRequest(j, gist, status)
+		return
+	}
+	if skip != "" {
+		w.skipJobRequest(j, gist, status, skip)
+		return
+	}
+	w.muActive.Lock()
+	delete(w.active, j.id)
+	w.muActive.Unlock()
+	j.out.close()
+	w.h.update(j.id, func(r *jobRecord) {
+		r.Duration = time.Since(start)
+		if failed {
+			r.State = "failure"
+		} else {
+			r.State = "success"
+		}
+	})
+	w.notify(j.id, "finished")
+	if failed {
+		w.deploymentStatus(j, "failure", gist.url())
+	} else {
+		w.deploymentStatus(j, "success", gist.url())
+	}
 
 	// This requires OAuth scope 'public_repo' or 'repo'. The problem is that
 	// this gives full write access, not just issue creation and this is
-	// problematic with the current security design of this project. Leave the
-	// code there as this is harmless and still work is people do not care about
-	// security.
-	if failed && len(blame) != 0 {
-		title := fmt.Sprintf("Build %q failed on %s", w.name, j.commitHash)
-		log.Printf("- Failed: %s", title)
-		log.Printf("- Blame: %v", blame)
-		// createIssue(j, gist, blame, title)
-	}
-	log.Printf("- testing done: https://github.com/%s/commit/%s", j.getID(), j.commitHash[:12])
+	// problematic with the current security design of this project. This is
+	// why it is opt-in.
+	if failed && len(j.blame) != 0 {
+		j.log.Warn("failed", "blame", j.blame)
+		if j.breakageIssues && !j.restricted {
+			w.reportBreakage(j)
+		}
+	}
+	if !failed && len(j.blame) != 0 && j.breakageIssues && !j.restricted {
+		w.closeBreakage(j)
+	}
+	j.log.Info("done", "url", fmt.Sprintf("https://github.com/%s/commit/%s", j.getID(), j.commitHash[:12]))
+}
+
+// skipJobRequest drops a job that shouldn't run, e.g. because its PR head no
+// longer exists.
+func (w *workerQueue) skipJobRequest(j *jobRequest, gist *gistOutput, status *github.RepoStatus, reason string) {
+	desc := "skipped: " + reason
+	j.log.Info("skipping", "reason", desc)
+	// There's no "skipped" state. "success" is the only one that isn't red nor
+	// stuck as pending.
+	w.endJobRequest(j, gist, status, "skipped", "success", desc)
+}
+
+// cancelJobRequest marks a job canceled, via the admin API or a comment.
+func (w *workerQueue) cancelJobRequest(j *jobRequest, gist *gistOutput, status *github.RepoStatus) {
+	desc := context.Cause(j.ctx).Error()
+	j.log.Info("canceled", "reason", desc)
+	w.endJobRequest(j, gist, status, "canceled", "error", desc)
+}
+
+// endJobRequest does the final update of a job that didn't run to completion.
+func (w *workerQueue) endJobRequest(j *jobRequest, gist *gistOutput, status *github.RepoStatus, state, statusState, desc string) {
+	w.muActive.Lock()
+	delete(w.active, j.id)
+	w.muActive.Unlock()
+	j.out.close()
+	w.h.update(j.id, func(r *jobRecord) {
+		r.State = state
+	})
+	w.notify(j.id, "finished")
+	w.deploymentStatus(j, statusState, gist.url())
+	gist.setSuffix(" " + desc)
+	w.gist(j, gist)
+	status.State = github.String(statusState)
+	status.Description = github.String(desc)
+	w.status(j, status)
 }
 
 // runJobRequestInner is the inner loop of runJobRequest. It updates gist as the
 // checks are progressing.
 //
-// Returns true if it failed.
-func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, status *github.RepoStatus) bool {
+// Returns true if it failed, or the reason if the job was skipped.
+func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *gistOutput, status *github.RepoStatus) (bool, string) {
 	// The function exits once results is closed by the goroutine below.
 	w.wg.Add(1)
 	defer w.wg.Done()
@@ -151,6 +638,13 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 		gist   gistFile
 	}
 	cc := make(chan up)
+	// The metadata updated with "go env" once the toolchain is set up.
+	meta := make(chan string, 1)
+	// skip, missing and unsigned are only accessed by the goroutine until results is
+	// closed.
+	skip := ""
+	missing := ""
+	unsigned := false
 	go func() {
 		defer close(results)
 
@@ -158,6 +652,9 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 		// Just in case a previous run left junk around. It should normally be
 		// silent.
 		// TODO(maruel): Fix numbering.
+		if killed := w.procs.killStale(); len(killed) != 0 {
+			results <- gistFile{"setup-0-stale", fmt.Sprintf("Killed the process groups left behind by previous jobs: %v\n", killed), true, 0}
+		}
 		j.cleanup("setup-0-precleanup", results)
 
 		// Phase 1: clone.
@@ -169,9 +666,95 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 			j.cleanup("setup-3-post-cleanup", results)
 			return
 		}
+		if len(j.signingKeys) != 0 {
+			out, err := verifySignature(filepath.Join(j.gopath, "src", j.getPath()), "HEAD", j.signingKeys)
+			if err != nil {
+				out += err.Error() + "\n"
+			}
+			results <- gistFile{"setup-1-signature", out, err == nil, 0}
+			if err != nil {
+				// Nothing from this commit may run on the device.
+				unsigned = true
+				j.cleanup("setup-3-post-cleanup", results)
+				return
+			}
+		}
+		if !j.runHooks("setup-1-pre-job", w.c.PreJob, results) {
+			j.runHooks("setup-3-post-job", w.c.PostJob, results)
+			j.cleanup("setup-3-post-cleanup", results)
+			return
+		}
 
 		// Phase 2: parse config.
-		chks, note := j.parseConfig(w.name)
+		var chks []gohci.Check
+		var note string
+		skipped := false
+		if j.restricted {
+			// Never trust the .gohci.yml from an untrusted PR.
+			chks, note = w.c.ForkChecks, "Using the worker's restricted checks for a PR from an untrusted user"
+		} else {
+			chks, note, skipped = j.parseConfig(w.name)
+		}
+		if w.c.MatchGoVersion && !skipped {
+			/* #nosec G304 */
+			if b, err := os.ReadFile(filepath.Join(j.gopath, "src", j.getPath(), "go.mod")); err == nil {
+				if tc := goModToolchain(string(b)); tc != "" {
+					// The go command downloads it as needed.
+					j.env = append(j.env, "GOTOOLCHAIN="+tc)
+					note += "\nUsing " + tc + " per go.mod"
+				}
+			}
+		}
+		if !skipped {
+			if e := j.goEnv(); e != "" {
+				meta <- j.meta + "\ngo env:\n" + e + "\n"
+			}
+		}
+		if !skipped && len(j.checks) != 0 {
+			chks = selectChecks(chks, j.checks)
+			note += fmt.Sprintf("\nOnly running checks %v", j.checks)
+		}
+		if skipped || len(chks) == 0 {
+			skip = "draft PR"
+			if !skipped {
+				note += "\nNo check to run"
+				skip = "no checks"
+			}
+			results <- gistFile{"setup-2-checks", note, true, 0}
+			j.runHooks("setup-3-post-job", w.c.PostJob, results)
+			j.cleanup("setup-3-post-cleanup", results)
+			return
+		}
+		if j.changedOnly && j.pullID != 0 && !j.restricted {
+			// Only test what the PR changed; the default branch is always fully
+			// tested.
+			start := time.Now()
+			if files, err := w.prFiles(j.org, j.repo, j.pullID); err != nil {
+				results <- gistFile{"setup-2-changed", fmt.Sprintf("Failed to list the PR's files, running all the packages: %v\n", err), true, time.Since(start)}
+			} else {
+				out, ok := j.changedPackages(files)
+				results <- gistFile{"setup-2-changed", out, ok, time.Since(start)}
+			}
+		}
+		if j.devices != nil {
+			if m := preflight(j.devices); len(m) != 0 {
+				missing = strings.Join(m, ", ")
+				results <- gistFile{"setup-2-preflight", "hardware missing: " + missing + "\n", false, 0}
+				j.runHooks("setup-3-post-job", w.c.PostJob, results)
+				j.cleanup("setup-3-post-cleanup", results)
+				return
+			}
+		}
+		if j.reset != nil {
+			start := time.Now()
+			out, ok := j.resetDevice()
+			results <- gistFile{"setup-2-reset", out, ok, time.Since(start)}
+			if !ok {
+				j.runHooks("setup-3-post-job", w.c.PostJob, results)
+				j.cleanup("setup-3-post-cleanup", results)
+				return
+			}
+		}
 		// TODO(maruel): Validate!
 		// Use a different channel to send this update to send also the number of
 		// checks.
@@ -180,10 +763,40 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 			gist:   gistFile{"setup-2-checks", note + "\nCommands to be run:\n" + cmds(chks), true, 0},
 		}
 
+		if j.deployment != nil && !j.restricted && !j.local {
+			w.createDeployment(j, gist.url())
+		}
+
 		// Phase 3: checks.
-		j.runChecks(chks, results)
+		var samples <-chan string
+		stop := make(chan struct{})
+		if w.c.TelemetryInterval > 0 {
+			samples = startTelemetry(w.c.TelemetryInterval, stop)
+		}
+		var consoles []<-chan gistFile
+		for _, p := range j.serial {
+			consoles = append(consoles, startSerial(p, stop))
+		}
+		passed := j.runChecks(chks, results)
+		close(stop)
+		if samples != nil {
+			results <- gistFile{"setup-3-telemetry", <-samples, true, 0}
+		}
+		for _, c := range consoles {
+			results <- <-c
+		}
+		if !passed && len(j.blame) != 0 && !j.restricted && j.bisectCheck > 0 && j.bisectCheck <= len(chks) {
+			// Bisect a regression of the default branch.
+			c := chks[j.bisectCheck-1]
+			if good := lastGood(w.h.list(jobFilter{Org: j.org, Repo: j.repo}), j.id, j.ref); good != "" && len(missingLabels(c.Requires, j.labels)) == 0 {
+				start := time.Now()
+				out, ok := j.bisect(good, c)
+				results <- gistFile{"setup-3-bisect", out, ok, time.Since(start)}
+			}
+		}
 
 		// Phase 4: cleanup.
+		j.runHooks("setup-3-post-job", w.c.PostJob, results)
 		j.cleanup("setup-3-post-cleanup", results)
 	}()
 
@@ -191,18 +804,58 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 	checkNum := 0
 	failed := 0
 	total := 0
+	// In quiet mode, the gist and status are only updated once at the end.
+	quiet := w.c.ReportingMode == "quiet"
 	status.Description = github.String("Setting up")
-	w.status(j, status)
-	// Keep a backup of the gist description, will be reused.
-	gistDesc := *gist.Description
+	if !quiet {
+		w.status(j, status)
+	}
 	var delay <-chan time.Time
+	// The output of a long command is uploaded while it runs.
+	var partial <-chan time.Time
+	if !quiet && !j.local {
+		t := time.NewTicker(partialInterval)
+		defer t.Stop()
+		partial = t.C
+	}
+	lastPartial := ""
+	var summary []mdResult
+	var collapse *setupCollapser
+	if w.c.CollapseSetup {
+		collapse = &setupCollapser{}
+	}
+	var heartbeat <-chan time.Time
+	if !quiet {
+		t := time.NewTicker(heartbeatInterval)
+		defer t.Stop()
+		heartbeat = t.C
+	}
 	for {
 		select {
 		case <-delay:
-			w.gist(gist)
+			w.gist(j, gist)
 			w.status(j, status)
 			delay = nil
 
+		case <-partial:
+			if p := j.partialOutput(); p != lastPartial && p != "" {
+				w.uploadPartial(j, gist, p)
+				lastPartial = p
+			}
+
+		case now := <-heartbeat:
+			if h := j.heartbeat(now); h != "" {
+				// The next result overwrites it.
+				status.Description = github.String(h)
+				w.status(j, status)
+			}
+
+		case m := <-meta:
+			gist.add("setup-0-metadata", m)
+			if !quiet && delay == nil {
+				delay = time.After(time.Second)
+			}
+
 		case c := <-cc:
 			// Similar to results but includes updating total.
 			total = c.checks
@@ -211,16 +864,56 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 		case r, ok := <-results:
 			if !ok {
 				// The channel closed. Do one last update if necessary then quit.
-				if delay != nil {
-					w.gist(gist)
+				if lastPartial != "" {
+					w.uploadPartial(j, gist, "")
+				}
+				if collapse != nil {
+					collapse.flush(gist)
+				}
+				if w.c.MarkdownSummary && skip == "" {
+					gist.add(gistSummaryFile, renderMarkdown(gist.desc, summary, time.Since(start1)))
+				}
+				if skip != "" {
+					// The caller does the final update.
+					return false, skip
+				}
+				if unsigned {
+					status.Description = github.String("Commit signature not verified")
+					gist.setSuffix(" signature not verified")
+					w.gist(j, gist)
+					w.status(j, status)
+					return true, ""
+				}
+				if missing != "" {
+					// Make it clear it's not a test failure.
+					status.Description = github.String(truncateDescription("Hardware missing: " + missing))
+					gist.setSuffix(" hardware missing")
+					w.gist(j, gist)
+					w.status(j, status)
+					return true, ""
+				}
+				// The summary and the collapsed setup steps may still be pending.
+				if delay != nil || quiet || len(gist.pending) != 0 {
+					w.gist(j, gist)
 					w.status(j, status)
 				}
-				return failed != 0
+				return failed != 0, ""
+			}
+			if lastPartial != "" {
+				// The command completed.
+				w.uploadPartial(j, gist, "")
+				lastPartial = ""
 			}
 			// https://developer.github.com/v3/gists/#edit-a-gist
 			if len(r.content) == 0 {
 				r.content = "<missing>"
 			}
+			w.h.update(j.id, func(h *jobRecord) {
+				h.Checks = append(h.Checks, checkResult{r.name, r.success, r.d})
+			})
+			if w.c.MarkdownSummary {
+				summary = append(summary, mdResult{r.name, r.content, r.success, r.d})
+			}
 
 			firstFailure := false
 			if !r.success {
@@ -232,7 +925,13 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 				failed++
 			}
 			r.name += " in " + roundDuration(r.d).String()
-			gist.Files[github.GistFilename(r.name)] = github.GistFile{Content: &r.content}
+			if collapse == nil || !collapse.add(r.name, r.content, r.success) {
+				if collapse != nil {
+					// Keep the order.
+					collapse.flush(gist)
+				}
+				gist.add(r.name, r.content)
+			}
 
 			// Update status and gist description. The suffix is used for both.
 			suffix := ""
@@ -263,12 +962,15 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 			}
 			// Always add duration up to now.
 			suffix += " in " + roundDuration(time.Since(start1)).String()
-			gist.Description = github.String(gistDesc + suffix)
+			gist.setSuffix(suffix)
 			status.Description = github.String(statusDesc + suffix)
 
+			if quiet {
+				continue
+			}
 			// On first failure, do not wait.
 			if firstFailure {
-				w.gist(gist)
+				w.gist(j, gist)
 				w.status(j, status)
 				delay = nil
 			} else if delay == nil {
@@ -281,32 +983,37 @@ func (w *workerQueue) runJobRequestInner(j *jobRequest, gist *github.Gist, statu
 	}
 }
 
+// goEnv returns the go tool environment variables set by the worker config.
+func goEnv(c *gohci.WorkerConfig) []string {
+	var out []string
+	if c.Offline {
+		out = append(out, "GOPROXY=off")
+	} else if c.GoProxy != "" {
+		out = append(out, "GOPROXY="+c.GoProxy)
+	}
+	if c.GoFlags != "" {
+		out = append(out, "GOFLAGS="+c.GoFlags)
+	}
+	return out
+}
+
 // status calls into w.client.Repositories.CreateStatus().
 func (w *workerQueue) status(j *jobRequest, status *github.RepoStatus) bool {
+	if j.local {
+		j.log.Info("status", "state", status.GetState(), "description", status.GetDescription())
+		return true
+	}
 	if _, _, err := w.client.Repositories.CreateStatus(w.ctx, j.org, j.repo, j.commitHash, status); err != nil {
 		if status.ID != nil {
-			log.Printf("- failed to update status: %v", err)
+			j.log.Error("failed to update status", "err", err)
 		} else {
-			log.Printf("- Failed to create status: %v", err)
+			j.log.Error("failed to create status", "err", err)
 		}
 		return false
 	}
 	return true
 }
 
-// gist calls into w.client.Gists.Edit().
-//
-// It clears the file mapping to reduce I/O, since files are automatically
-// carried over.
-func (w *workerQueue) gist(gist *github.Gist) bool {
-	if _, _, err := w.client.Gists.Edit(w.ctx, *gist.ID, gist); err != nil {
-		log.Printf("- failed to update gist: %v", err)
-		return false
-	}
-	gist.Files = map[github.GistFilename]github.GistFile{}
-	return true
-}
-
 //
 
 // cmds returns the list of commands to attach to the metadata gist as a single
@@ -320,7 +1027,12 @@ func cmds(checks []gohci.Check) string {
 		if len(c.Env) != 0 {
 			cmds += "  " + strings.Join(c.Env, " ")
 		}
-		cmds += "  " + strings.Join(c.Cmd, " ")
+		cmd, err := checkCmd(&c)
+		if err != nil {
+			cmds += "  " + err.Error()
+			continue
+		}
+		cmds += "  " + strings.Join(cmd, " ")
 	}
 	return cmds
 }
@@ -0,0 +1,219 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gohci
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"periph.io/x/gohci/internal/valid"
+)
+
+// WorkerConfigVersion is the current layout of WorkerConfig.
+const WorkerConfigVersion = 1
+
+// githubKnownHosts are GitHub's SSH host keys as published at
+// https://api.github.com/meta.
+//
+// Only the ed25519 and ECDSA keys are listed; ssh prefers the key types found
+// in known_hosts.
+var githubKnownHosts = []string{
+	"github.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl",
+	"github.com ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEmKSENjQEezOmxkZMy7opKgwFB9nkt5YRrYMjNuG5N87uRgg6CLrbo5wAdT/y6v0mKV0U2w0WZ2YB/++Tpockg=",
+}
+
+// DefaultWorkerConfig returns the default worker configuration.
+//
+// Name and WebHookSecret are left empty.
+func DefaultWorkerConfig() *WorkerConfig {
+	return &WorkerConfig{
+		Version:              WorkerConfigVersion,
+		Port:                 8080,
+		Oauth2AccessToken:    "Get one at https://github.com/settings/tokens",
+		HistoryMaxJobs:       1000,
+		HistoryMaxAge:        90 * 24 * time.Hour,
+		WebhookMaxDeliveries: 100,
+		ReportingMode:        "incremental",
+		LogLevel:             "info",
+		LogFormat:            "text",
+		LogFileMaxSize:       10 * 1024 * 1024,
+		LogFileMaxAge:        7 * 24 * time.Hour,
+		LogFileMaxBackups:    5,
+		SSHKnownHosts:        githubKnownHosts,
+		DispatchEventType:    "gohci",
+		RestrictedCommands:   []string{"go", "gofmt", "git"},
+		WarmAt:               "03:00",
+		GCSURLExpiry:         7 * 24 * time.Hour,
+		AzureURLExpiry:       7 * 24 * time.Hour,
+		ExportMethod:         "rsync",
+	}
+}
+
+// Validate returns an error if a setting has an invalid value or if settings
+// can't be used together.
+//
+// It is used by gohci-worker on startup and by gohci-check.
+func (c *WorkerConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.WebHookSecret == "" {
+		return errors.New("webhooksecret is required")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d; use 1 to 65535", c.Port)
+	}
+	if c.ReportingMode != "" && c.ReportingMode != "incremental" && c.ReportingMode != "quiet" {
+		return fmt.Errorf("invalid reportingmode %q; use \"incremental\" or \"quiet\"", c.ReportingMode)
+	}
+	if c.Coordinator && c.CoordinatorURL != "" {
+		return errors.New("coordinator and coordinatorurl are mutually exclusive")
+	}
+	if c.CoordinatorURL != "" && c.AdvertiseURL == "" {
+		return errors.New("advertiseurl is required with coordinatorurl")
+	}
+	for _, r := range c.PollRepos {
+		if !valid.OrgRepo(r) {
+			return fmt.Errorf("invalid pollrepos entry %q; use \"org/repo\"", r)
+		}
+	}
+	if len(c.PollRepos) != 0 && (c.Coordinator || c.CoordinatorURL != "") {
+		return errors.New("pollrepos can't be used with a coordinator")
+	}
+	for _, r := range c.GitRemotes {
+		if !valid.OrgRepo(r.Name) || r.URL == "" {
+			return fmt.Errorf("invalid gitremotes entry %q; name must be \"org/repo\" and url is required", r.Name)
+		}
+		for _, b := range r.Branches {
+			if !valid.Ref(b) {
+				return fmt.Errorf("invalid gitremotes branch %q", b)
+			}
+		}
+	}
+	if c.Tunnel != "" && c.Tunnel != "cloudflared" && c.Tunnel != "ngrok" {
+		return fmt.Errorf("invalid tunnel %q; use \"cloudflared\" or \"ngrok\"", c.Tunnel)
+	}
+	for _, n := range c.Notifications {
+		if len(n.Email) != 0 && (c.SMTPServer == "" || c.SMTPFrom == "") {
+			return errors.New("smtpserver and smtpfrom are required to send emails")
+		}
+		if n.MatrixRoom != "" && (n.MatrixHomeserver == "" || n.MatrixToken == "") {
+			return errors.New("matrixhomeserver and matrixtoken are required with matrixroom")
+		}
+	}
+	for _, r := range c.WarmRepos {
+		if !valid.OrgRepo(r) {
+			return fmt.Errorf("invalid warmrepos entry %q; use \"org/repo\"", r)
+		}
+	}
+	for _, p := range c.Projects {
+		if _, err := path.Match(p.Name, ""); err != nil || !valid.OrgRepo(p.Name) {
+			return fmt.Errorf("invalid projects name %q; use \"org/repo\" or a pattern like \"org/*\"", p.Name)
+		}
+		if p.ChecksMode != "" && p.ChecksMode != "default" && p.ChecksMode != "override" && p.ChecksMode != "append" {
+			return fmt.Errorf("invalid projects checksmode %q; use \"default\", \"override\" or \"append\"", p.ChecksMode)
+		}
+		for _, k := range p.SigningKeys {
+			if !valid.SigningKey(k) {
+				return fmt.Errorf("invalid signingkeys entry %q; use a SSH public key or a GPG fingerprint", k)
+			}
+		}
+		for i := range p.Checks {
+			if err := p.Checks[i].Validate(); err != nil {
+				return fmt.Errorf("projects %s check %d: %w", p.Name, i+1, err)
+			}
+		}
+	}
+	for i := range c.ForkChecks {
+		if err := c.ForkChecks[i].Validate(); err != nil {
+			return fmt.Errorf("forkchecks check %d: %w", i+1, err)
+		}
+	}
+	if c.RejectUnknownRepos && len(c.Projects) == 0 {
+		return errors.New("rejectunknownrepos requires projects")
+	}
+	if c.SelfTestRepo != "" && !valid.OrgRepo(c.SelfTestRepo) {
+		return fmt.Errorf("invalid selftestrepo %q; use \"org/repo\"", c.SelfTestRepo)
+	}
+	if _, err := time.Parse("15:04", c.WarmAt); len(c.WarmRepos) != 0 && err != nil {
+		return fmt.Errorf("invalid warmat %q; use \"HH:MM\"", c.WarmAt)
+	}
+	if c.GistMaxAge < 0 {
+		return fmt.Errorf("invalid gistmaxage %s; use 0 or a positive duration", c.GistMaxAge)
+	}
+	if c.GCSBucket != "" && c.GCSCredentials == "" {
+		return fmt.Errorf("gcsbucket %q requires gcscredentials", c.GCSBucket)
+	}
+	if c.GCSURLExpiry <= 0 || c.GCSURLExpiry > 7*24*time.Hour {
+		return fmt.Errorf("invalid gcsurlexpiry %s; use up to 168h", c.GCSURLExpiry)
+	}
+	if c.AzureAccount != "" {
+		if c.GCSBucket != "" {
+			return fmt.Errorf("use either gcsbucket %q or azureaccount %q", c.GCSBucket, c.AzureAccount)
+		}
+		if c.AzureContainer == "" || c.AzureKey == "" {
+			return fmt.Errorf("azureaccount %q requires azurecontainer and azurekey", c.AzureAccount)
+		}
+		if _, err := base64.StdEncoding.DecodeString(c.AzureKey); err != nil {
+			return fmt.Errorf("invalid azurekey: %w", err)
+		}
+	}
+	if c.ExportTarget != "" && (c.GCSBucket != "" || c.AzureAccount != "") {
+		return fmt.Errorf("use either exporttarget %q, gcsbucket or azureaccount", c.ExportTarget)
+	}
+	if c.ExportMethod != "" && c.ExportMethod != "rsync" && c.ExportMethod != "sftp" {
+		return fmt.Errorf("invalid exportmethod %q; use \"rsync\" or \"sftp\"", c.ExportMethod)
+	}
+	if _, _, ok := strings.Cut(c.ExportTarget, ":"); c.ExportTarget != "" && c.ExportMethod == "sftp" && !ok {
+		return fmt.Errorf("invalid exporttarget %q; use \"[user@]host:path\"", c.ExportTarget)
+	}
+	if c.AzureURLExpiry <= 0 {
+		return fmt.Errorf("invalid azureurlexpiry %s; use a positive duration", c.AzureURLExpiry)
+	}
+	if c.GistMaxPerRepo < 0 {
+		return fmt.Errorf("invalid gistmaxperrepo %d; use 0 or a positive value", c.GistMaxPerRepo)
+	}
+	if c.Nice < -20 || c.Nice > 19 {
+		return fmt.Errorf("invalid nice %d; use -20 to 19", c.Nice)
+	}
+	if c.IONice != "" && c.IONice != "idle" && c.IONice != "best-effort" {
+		return fmt.Errorf("invalid ionice %q; use \"idle\" or \"best-effort\"", c.IONice)
+	}
+	if c.ReferenceDir != "" && !filepath.IsAbs(c.ReferenceDir) {
+		return fmt.Errorf("referencedir %q must be an absolute path", c.ReferenceDir)
+	}
+	if c.Offline && c.MatchGoVersion {
+		return errors.New("matchgoversion doesn't work with offline")
+	}
+	if c.Offline && c.GoProxy != "" {
+		return errors.New("goproxy doesn't make sense with offline")
+	}
+	return nil
+}
+
+// Validate returns an error if the priority or the CPU affinity of the check
+// is invalid.
+//
+// It is used by gohci-worker on the checks of the worker's config and of the
+// repositories' .gohci.yml.
+func (c *Check) Validate() error {
+	if c.Nice != nil && (*c.Nice < -20 || *c.Nice > 19) {
+		return fmt.Errorf("invalid nice %d; use -20 to 19", *c.Nice)
+	}
+	if c.IONice != "" && c.IONice != "idle" && c.IONice != "best-effort" {
+		return fmt.Errorf("invalid ionice %q; use \"idle\" or \"best-effort\"", c.IONice)
+	}
+	for _, cpu := range c.CPUs {
+		if cpu < 0 {
+			return fmt.Errorf("invalid cpus entry %d", cpu)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package gohci
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	c := DefaultWorkerConfig()
+	if err := c.Validate(); err == nil || err.Error() != "name is required" {
+		t.Fatal(err)
+	}
+	c.Name = "w"
+	c.WebHookSecret = "secret"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	data := []struct {
+		mod func(c *WorkerConfig)
+		err string
+	}{
+		{func(c *WorkerConfig) { c.Port = 0 }, "invalid port"},
+		{func(c *WorkerConfig) { c.ReportingMode = "loud" }, "invalid reportingmode"},
+		{func(c *WorkerConfig) { c.PollRepos = []string{"periph"} }, "invalid pollrepos"},
+		{func(c *WorkerConfig) {
+			c.GitRemotes = []GitRemote{{Name: "lab/fw", URL: "u", Branches: []string{"a..b"}}}
+		}, "invalid gitremotes branch"},
+		{func(c *WorkerConfig) { c.Projects = []Project{{Name: "periph/["}} }, "invalid projects name"},
+		{func(c *WorkerConfig) { c.Projects = []Project{{Name: "periph/*", SigningKeys: []string{"bad"}}} }, "invalid signingkeys"},
+		{func(c *WorkerConfig) { c.RejectUnknownRepos = true }, "rejectunknownrepos requires projects"},
+		{func(c *WorkerConfig) {
+			c.Projects = []Project{{Name: "periph/*", Checks: []Check{{}, {IONice: "high"}}}}
+		}, "projects periph/* check 2: invalid ionice"},
+		{func(c *WorkerConfig) { c.ForkChecks = []Check{{CPUs: []int{-1}}} }, "forkchecks check 1: invalid cpus"},
+		{func(c *WorkerConfig) { c.GCSURLExpiry = 0 }, "invalid gcsurlexpiry"},
+		{func(c *WorkerConfig) { c.ReferenceDir = "relative" }, "must be an absolute path"},
+		{func(c *WorkerConfig) { c.Offline, c.MatchGoVersion = true, true }, "matchgoversion"},
+	}
+	for i, l := range data {
+		c := DefaultWorkerConfig()
+		c.Name = "w"
+		c.WebHookSecret = "secret"
+		l.mod(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), l.err) {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestCheckValidate(t *testing.T) {
+	n := func(v int) *int { return &v }
+	for i, c := range []Check{{}, {Nice: n(0)}, {Nice: n(-20)}, {IONice: "idle", CPUs: []int{0, 3}}} {
+		if err := c.Validate(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+	for i, c := range []Check{{Nice: n(20)}, {Nice: n(-21)}, {IONice: "rt"}, {CPUs: []int{-1}}} {
+		if err := c.Validate(); err == nil {
+			t.Fatalf("#%d: expected error", i)
+		}
+	}
+}
@@ -1,22 +1,19 @@
 module periph.io/x/gohci
 
-go 1.17
+go 1.21
 
 require (
 	github.com/google/go-github/v31 v31.0.0
 	github.com/pbnjay/memory v0.0.0-20210728143218-7b4eea64cf58
-	golang.org/x/oauth2 v0.1.0
+	go.etcd.io/bbolt v1.3.9
 	gopkg.in/fsnotify.v1 v1.4.7
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/fsnotify/fsnotify v1.4.9 // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/google/go-cmp v0.5.8 // indirect
 	github.com/google/go-querystring v1.1.0 // indirect
 	golang.org/x/crypto v0.1.0 // indirect
-	golang.org/x/net v0.1.0 // indirect
-	golang.org/x/sys v0.1.0 // indirect
-	google.golang.org/appengine v1.6.7 // indirect
-	google.golang.org/protobuf v1.28.1 // indirect
+	golang.org/x/sys v0.4.0 // indirect
 )
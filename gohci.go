@@ -11,10 +11,15 @@
 // secret and OAuth2 access token.
 package gohci
 
+import "time"
+
 // WorkerConfig is a worker configuration.
 //
 // It is found as `gohci.yml` in the gohci-worker working directory.
 type WorkerConfig struct {
+	// Version is the layout of this file, currently 1. gohci-worker migrates
+	// the older layouts automatically.
+	Version int
 	// TCP port number for the HTTP server.
 	Port int
 	// WebHookSecret is the shared secret that keeps people on the internet from
@@ -29,17 +34,400 @@ type WorkerConfig struct {
 	//
 	// https://github.com/settings/tokens, check "repo:status" and "gist"
 	Oauth2AccessToken string
+	// Oauth2AccessTokens are additional tokens to fail over to, in order, when
+	// the current one is revoked or rate limited.
+	Oauth2AccessTokens []string
+	// GistToken, when set, is only used to create and edit the gists, so the
+	// results are owned by a separate bot account and the tokens above only
+	// need "repo:status". It needs the "gist" scope.
+	GistToken string
 	// Display name to use in the status report on Github.
 	//
 	// Defaults to the machine hostname.
 	Name string
+	// HistoryMaxJobs is the maximum number of jobs kept in the build history.
+	// 0 means unlimited.
+	HistoryMaxJobs int
+	// HistoryMaxAge is the maximum age of the jobs kept in the build history,
+	// e.g. "720h". 0 means unlimited.
+	HistoryMaxAge time.Duration
+	// GistMaxAge is the maximum age of the gists created by this worker, e.g.
+	// "8760h". Older ones are deleted once a day. 0 means unlimited.
+	GistMaxAge time.Duration
+	// GistMaxPerRepo is the maximum number of jobs whose gists created by this
+	// worker are kept per repository, the oldest ones are deleted once a day. A
+	// job's continuation gists count as one. 0 means unlimited.
+	GistMaxPerRepo int
+	// GCSBucket, when set, is the Google Cloud Storage bucket where the output
+	// of the jobs is uploaded instead of the gists. The commit status links to
+	// a signed URL of the job's index.html. Use a bucket lifecycle rule for
+	// the retention.
+	GCSBucket string
+	// GCSCredentials is the path to the service account JSON key file used to
+	// write to GCSBucket and sign the URLs.
+	GCSCredentials string
+	// GCSURLExpiry is the validity of the signed URLs, at most 7 days.
+	GCSURLExpiry time.Duration
+	// AzureAccount, when set, is the Azure storage account where the output of
+	// the jobs is uploaded in AzureContainer instead of the gists. The commit
+	// status links to a read-only SAS URL of the job's index.html. Use a
+	// lifecycle management policy for the retention.
+	AzureAccount string
+	// AzureContainer is the blob container in AzureAccount.
+	AzureContainer string
+	// AzureKey is the base64 encoded access key of AzureAccount, used to write
+	// the blobs and sign the SAS URLs.
+	AzureKey string
+	// AzureURLExpiry is the validity of the SAS URLs.
+	AzureURLExpiry time.Duration
+	// ExportTarget, when set, is where the output of the jobs is copied instead
+	// of the gists, as "[user@]host:path", e.g. the lab's NAS. It uses the SSH
+	// configuration and keys of the user running the worker.
+	ExportTarget string
+	// ExportMethod is either "rsync" (the default) or "sftp".
+	ExportMethod string
+	// ExportURL is the URL where ExportTarget is served, to link the commit
+	// status to the job's index.html. Optional.
+	ExportURL string
+	// ReportingMode is either "incremental" (the default) or "quiet".
+	//
+	// "incremental" updates the gist and commit status as each check completes.
+	// "quiet" skips the intermediate updates and uploads the results once at
+	// the end of the job, for metered connections or strict API quotas.
+	ReportingMode string
+	// CollapseSetup merges the output of the successful setup steps, like the
+	// clone and the cleanup, in a single "setup" gist file. A failed step
+	// keeps its own file.
+	CollapseSetup bool
+	// MarkdownSummary adds a "_summary.md" file to the gist, rendered by
+	// GitHub, with a summary header and a collapsible section per step. The
+	// failed steps are expanded.
+	MarkdownSummary bool
+	// WebhookMaxDeliveries is the number of raw webhook deliveries to keep, to
+	// be able to debug and replay them. 0 disables storing them.
+	WebhookMaxDeliveries int
+	// LogLevel is the minimum level to log, one of "debug", "info", "warn" or
+	// "error". Defaults to "info".
+	LogLevel string
+	// LogFormat is one of "text" (the default), "json", "journald" or
+	// "syslog". JSON is easier to ship to a log aggregator. "journald" sends
+	// the attributes as structured fields, e.g. "journalctl JOB_ID=42".
+	LogFormat string
+	// LogModules overrides LogLevel per module, e.g. {"job": "debug"}. Valid
+	// modules are "main", "server", "job" and "history".
+	LogModules map[string]string
+	// LogFile, when set, writes the logs to this file instead of stderr. It
+	// can only be used with the "text" and "json" formats.
+	LogFile string
+	// LogFileMaxSize is the size in bytes at which the log file is rotated. 0
+	// disables size based rotation.
+	LogFileMaxSize int64
+	// LogFileMaxAge is the age at which the log file is rotated, e.g. "24h". 0
+	// disables time based rotation.
+	LogFileMaxAge time.Duration
+	// LogFileMaxBackups is the number of rotated gzip compressed log files to
+	// keep. 0 keeps them all.
+	LogFileMaxBackups int
+	// ForkChecks are the checks to run on PRs from users that are not super
+	// users, which are otherwise ignored.
+	//
+	// The repository's .gohci.yml is not used for these PRs since it is under
+	// the contributor's control. Keep these to checks that are safe to run on
+	// untrusted code, e.g. "go build ./...", optionally wrapped in a sandbox.
+	// The environment is reduced to the bare minimum.
+	ForkChecks []Check
+	// PreJob and PostJob are commands run before and after every job, e.g. to
+	// power cycle a device under test or mount a tmpfs. They run from the
+	// job's GOPATH, after the clone and before the cleanup, and have the
+	// GOHCI_* variables. A PreJob failure aborts the job; PostJob still runs.
+	PreJob  []Check
+	PostJob []Check
+	// Timestamps prefixes each line of the checks' output with the time
+	// elapsed since the command started, e.g. "[00:03.241] ", to see where a
+	// long check spends its time.
+	Timestamps bool
+	// Nice is the niceness of the checks, from -20 to 19, e.g. 10 so a busy
+	// job doesn't make the device unusable for its other uses. IONice is the IO
+	// scheduling class of the checks, "idle" or "best-effort", on Linux only.
+	// They are applied with nice and ionice, except on Windows.
+	Nice   int
+	IONice string
+	// MemoryLimit kills a check when the resident memory of its processes
+	// exceeds this many bytes, before the kernel OOM killer picks the worker
+	// itself. Linux only. 0 disables it.
+	MemoryLimit int64
+	// ReuseWorkspace keeps the checkout between the jobs of a repository. It
+	// is updated with a fetch and a reset instead of cloning again, then
+	// cleaned with "git clean -ffdx" and verified to be at the expected commit.
+	// PRs from untrusted users always use a fresh checkout.
+	ReuseWorkspace bool
+	// Worktrees keeps a bare mirror of each repository in "mirrors" and checks
+	// out each job as a worktree of it, so the objects are only fetched once.
+	// PRs from untrusted users always use a fresh checkout.
+	Worktrees bool
+	// ReferenceDir is a directory where a bare mirror of each repository is
+	// kept up to date. The checkouts borrow its objects like "git clone
+	// --reference", so only the new objects are downloaded. Unused with
+	// Worktrees. PRs from untrusted users always use a fresh checkout.
+	ReferenceDir string
+	// WarmRepos are the "org/repo" to fetch with their Go modules every day at
+	// WarmAt, the "HH:MM" local time, so the first job of the day doesn't
+	// spend its time downloading. It waits for the running job to complete.
+	WarmRepos []string
+	WarmAt    string
+	// MatchGoVersion runs the checks with the Go toolchain requested by the
+	// repository's go.mod, per its toolchain line or else its go directive,
+	// via GOTOOLCHAIN. The go command downloads it as needed, so it requires
+	// the go in the PATH to be at least go1.21 and doesn't work with Offline.
+	// Versions before go1.21 are ignored.
+	MatchGoVersion bool
+	// TinyGoVersion is the TinyGo release to install in the worker directory
+	// and add to the PATH of the checks, e.g. "0.33.0". The tinygo in the PATH
+	// is used when empty.
+	TinyGoVersion string
+	// TelemetryInterval is how often the CPU temperature and the Raspberry Pi
+	// throttling state are sampled while the checks run. The samples are
+	// added to the gist. 0 disables sampling; the state at the start of the
+	// job is always in the metadata when available.
+	TelemetryInterval time.Duration
+	// RestrictedCommands are the only commands, as the first argument of Cmd,
+	// allowed in the checks of restricted jobs, i.e. the ForkChecks run for
+	// PRs from untrusted users. The match is exact, so "go" doesn't allow
+	// "./go". Defaults to "go", "gofmt" and "git".
+	RestrictedCommands []string
+	// TrustCollaborators asks GitHub whether the sender of an event has write
+	// access to the repository, in addition to the superUsers listed in the
+	// webhook URL. This requires a token that can read the collaborators'
+	// permission, e.g. a fine-grained token with "Metadata" read access.
+	TrustCollaborators bool
+	// TrustedConfig reads the .gohci.yml of a PR from its base branch instead
+	// of the PR itself, so a PR can't change what runs on the worker.
+	TrustedConfig bool
+	// AllowedOrgs and AllowedRepos restrict the repositories this worker runs
+	// jobs for, so knowing the webhook secret isn't enough to run code on the
+	// worker. AllowedOrgs lists organizations or users, e.g. "periph", and
+	// AllowedRepos lists "org/repo". Matching is case insensitive. When both are
+	// empty, all repositories are allowed.
+	AllowedOrgs  []string
+	AllowedRepos []string
+	// Projects are the repositories the worker knows about, with their default
+	// checks and super users.
+	Projects []Project
+	// RejectUnknownRepos refuses the jobs for the repositories not matching
+	// one of Projects, for locked-down deployments.
+	RejectUnknownRepos bool
+	// HTTPProxy and HTTPSProxy are the proxies to use to reach the internet,
+	// e.g. "http://proxy.lab:3128", for both the GitHub API and the git and go
+	// commands run by the checks. NoProxy is a comma separated list of hosts
+	// or domains to reach directly. When empty, the HTTP_PROXY, HTTPS_PROXY
+	// and NO_PROXY environment variables are honored as usual.
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	// SSHKnownHosts are known_hosts lines pinning the SSH host keys used when
+	// fetching private repositories over SSH. It defaults to GitHub's published
+	// keys. When empty, the device's ssh configuration is used as-is.
+	SSHKnownHosts []string
+	// ModuleToken is a GitHub token with read access to the private Go modules
+	// that projects depend on. It is only exposed to the checks of projects
+	// setting GoPrivate in their .gohci.yml, and never for PRs from untrusted
+	// users.
+	ModuleToken string
+	// GoProxy and GoFlags are set as GOPROXY and GOFLAGS for all the jobs, e.g.
+	// to use a module cache on the LAN like "http://athens.lab:3000".
+	GoProxy string
+	GoFlags string
+	// Offline sets GOPROXY=off so the go tool only uses the module cache. A
+	// missing module fails right away with a clear message instead of waiting
+	// on network timeouts.
+	Offline bool
+	// Labels are the capabilities of this worker, e.g. "rpi4" or "has-spi".
+	// GOOS and GOARCH, e.g. "linux" and "arm", are implicitly included.
+	Labels []string
+	// Coordinator makes this process a coordinator: it receives the GitHub
+	// webhook and forwards the deliveries to the registered workers instead of
+	// running jobs. The workers must use the same WebHookSecret.
+	Coordinator bool
+	// CoordinatorURL is the URL of the coordinator to register this worker
+	// with, e.g. "http://coordinator.lan:8080". AdvertiseURL is the URL at
+	// which the coordinator reaches this worker, e.g. "http://rpi4.lan:8080".
+	CoordinatorURL string
+	AdvertiseURL   string
+	// PollRepos makes the worker poll GitHub for new commits on the default
+	// branch and the open PRs of these "org/repo" repositories, instead of
+	// listening for webhooks. This is for workers behind a NAT that can't
+	// accept inbound connections.
+	PollRepos []string
+	// PollInterval is the delay between polls. Defaults to 5 minutes.
+	PollInterval time.Duration
+	// PollSuperUsers are the users whose PRs are tested when polling, like the
+	// webhook's superUsers query argument.
+	PollSuperUsers []string
+	// PollUseSSH fetches the polled repositories over SSH, for private
+	// repositories.
+	PollUseSSH bool
+	// RelayURL is a webhook relay channel to receive the webhook deliveries
+	// from, e.g. "https://smee.io/<channel>", so the worker doesn't need to
+	// accept inbound connections. The payloads are validated with
+	// WebHookSecret like direct deliveries.
+	RelayURL string
+	// Tunnel is "cloudflared" or "ngrok" to start a tunnel at startup, so the
+	// worker is reachable without configuring the router. The executable must
+	// be in PATH. TunnelToken is the tunnel token for cloudflared or the
+	// authtoken for ngrok.
+	Tunnel      string
+	TunnelToken string
+	// TunnelURL is the public URL of the tunnel. It is required for a
+	// cloudflared named tunnel. With ngrok, it is a reserved domain and the
+	// assigned URL is used when empty.
+	TunnelURL string
+	// TunnelWebhooks are the "org/repo" whose webhook is created or updated to
+	// point to the tunnel. Only the webhook pointing to this worker's current
+	// or previous tunnel, recorded in tunnel.txt, is updated. This requires
+	// the "admin:repo_hook" scope.
+	TunnelWebhooks []string
+	// SelfTestRepo is the "org/repo" where /api/v1/selftest sets a status on
+	// the default branch's head, e.g. a dummy repository. The status step is
+	// skipped when empty.
+	SelfTestRepo string
+	// DispatchEventType is the event_type of the repository_dispatch events
+	// that trigger a job. Defaults to "gohci".
+	DispatchEventType string
+	// DashboardUsers restricts the dashboard, the Atom feed and the jobs' history
+	// and output to these users, as "user:password" entries checked with HTTP
+	// basic authentication. The webhook secret is also accepted as a bearer
+	// token. When empty, these pages are public.
+	DashboardUsers []string
+	// GitRemotes are projects hosted on a plain git server. They are polled
+	// every PollInterval for new commits and can be triggered via the
+	// /api/v1/trigger endpoint. Their results are only kept locally, in the
+	// history and in the "results" directory.
+	GitRemotes []GitRemote
+	// Notifications are where to report the jobs, in addition to the commit
+	// status.
+	Notifications []Notification
+	// SMTPServer is the "host:port" of the SMTP server used to send the emails
+	// of Notification.Email. SMTPUser and SMTPPassword are optional. STARTTLS
+	// is used when the server supports it.
+	SMTPServer   string
+	SMTPUser     string
+	SMTPPassword string
+	// SMTPFrom is the sender of the emails.
+	SMTPFrom string
+}
+
+// Notification describes where to report the jobs of some repositories.
+type Notification struct {
+	// Repos are the "org" or "org/repo" to report. Defaults to all.
+	Repos []string
+	// Slack is the URL of a Slack incoming webhook. A message is posted when a
+	// job starts and when it finishes.
+	Slack string
+	// Email are the recipients of an email sent when a push to the default
+	// branch fails. It requires SMTPServer.
+	Email []string
+	// MatrixHomeserver, MatrixToken and MatrixRoom post a message in a Matrix
+	// room when a job finishes. MatrixHomeserver is the URL of the homeserver,
+	// e.g. "https://matrix.org", MatrixToken is the access token of the account
+	// posting and MatrixRoom is the room ID, e.g. "!abcdef:matrix.org".
+	MatrixHomeserver string
+	MatrixToken      string
+	MatrixRoom       string
+	// Webhook is a URL receiving a POST with the JSON summary of the job when
+	// it finishes. When WebhookSecret is set, the body is signed with
+	// HMAC-SHA256 in the "X-Gohci-Signature-256" header as "sha256=<hex>", like
+	// GitHub does.
+	Webhook       string
+	WebhookSecret string
+	// MQTTBroker is the "tcp://host:port" or "ssl://host:port" of an MQTT
+	// broker to publish the JSON summary of the job to when it starts and
+	// finishes. The topic is "<MQTTTopic>/<worker>/<org>/<repo>", where
+	// MQTTTopic defaults to "gohci". The messages are retained, so a new
+	// subscriber gets the last state right away.
+	MQTTBroker   string
+	MQTTUser     string
+	MQTTPassword string
+	MQTTTopic    string
+}
+
+// Project is a worker side definition of a repository, or of a set of
+// repositories.
+type Project struct {
+	// Name is "org/repo". It is a pattern as understood by path.Match, e.g.
+	// "periph/*" matches all the repositories of the periph organization.
+	// Matching is case insensitive.
+	Name string
+	// Checks are the default checks, used when the repository's .gohci.yml
+	// doesn't exist or has no checks for this worker.
+	Checks []Check
+	// ChecksMode is how Checks apply when the repository's .gohci.yml has
+	// checks for this worker: "default" to use the repository's, "override" to
+	// use Checks instead, or "append" to run Checks after the repository's.
+	// Defaults to "default".
+	ChecksMode string
+	// SuperUsers are the users, or "org/team" teams, trusted to run jobs on
+	// the PRs of the project, in addition to the superUsers listed in the
+	// webhook URL.
+	SuperUsers []string
+	// SigningKeys, when set, requires the tested commit to be signed by one of
+	// these keys before anything is run. They are SSH public keys, e.g.
+	// "ssh-ed25519 AAAA...", or GPG key fingerprints. The GPG public keys must
+	// be imported in the worker's keyring.
+	SigningKeys []string
+	// Requires are the labels a worker must have to be dispatched the jobs of
+	// the project by a coordinator, e.g. "rpi4".
+	Requires []string
+}
+
+// GitRemote is a project hosted on a plain git server, without a forge.
+type GitRemote struct {
+	// Name identifies the project as "org/repo", e.g. "lab/firmware". It
+	// doesn't need to exist on GitHub.
+	Name string
+	// URL is the git URL to clone from.
+	URL string
+	// Branches are the branches to poll. Defaults to the remote's HEAD.
+	Branches []string
 }
 
 // Check is a single command to run.
+//
+// $VAR and ${VAR} in Cmd, Env values and Dir are expanded, including the
+// GOHCI_* variables and the ones defined earlier in Env.
 type Check struct {
 	Cmd []string // Command to run.
 	Env []string // Optional environment variables to use.
-	Dir string   // Directory to run from. Defaults to the root of the checkout.
+	// Dir is the directory to run from, relative to the root of the checkout,
+	// which is the default. It must resolve within the checkout, including via
+	// symlinks.
+	Dir string
+	// Requires are the worker labels needed to run this check. The check is
+	// skipped on workers missing one of them.
+	Requires []string
+	// Locks are the named hardware resources used by this check, e.g. "spi0"
+	// or "camera". The worker never runs two checks holding the same lock at
+	// the same time.
+	Locks []string
+	// GoFlags are added to GOFLAGS, e.g. "-race -count=1". Tags are the build
+	// tags, e.g. "periphtest" for the tests needing the hardware, added to
+	// GOFLAGS as -tags. GoExperiment is set as GOEXPERIMENT, e.g. "rangefunc".
+	GoFlags      string
+	Tags         []string
+	GoExperiment string
+	// Nice and IONice override the worker's priority for this check. Nice is
+	// unset by default, so "nice: 0" runs the check at the normal priority on
+	// a worker with a nice value.
+	Nice   *int
+	IONice string
+	// CPUs pins the check to these CPUs with taskset, on Linux only, e.g. the
+	// big cores of a big.LITTLE SoC for stable benchmarks.
+	CPUs []int
+	// Flash flashes a firmware to the device under test, instead of running
+	// Cmd. It is retried on failure.
+	Flash *Flash
+	// TinyGo runs tinygo for a microcontroller, instead of running Cmd. A
+	// flash is retried on failure.
+	TinyGo *TinyGo
 }
 
 // ProjectWorkerConfig is the project configuration via ".gohci.yml" for a
@@ -52,6 +440,127 @@ type ProjectWorkerConfig struct {
 	// Checks are the commands to run to test the repository. They are run one
 	// after the other from the repository's root.
 	Checks []Check
+	// Reset is done before the checks, so the hardware attached to the worker
+	// starts each job from a known state.
+	Reset *Reset
+	// Serial are the serial ports to capture while the checks run, e.g. the
+	// console of a microcontroller under test. Each transcript is added to the
+	// gist.
+	Serial []SerialPort
+	// Devices are verified to be present before the checks. The job fails
+	// right away with "hardware missing" otherwise.
+	Devices *Devices
+	// Cross is the cross compilation toolchain set in the environment of the
+	// checks, e.g. to build cgo based drivers for a Raspberry Pi on a x86
+	// worker.
+	Cross *Cross
+	// Deployment reports the job as a GitHub deployment to this environment,
+	// in addition to the commit status, e.g. when the checks flash a firmware
+	// to the device under test. It is ignored for restricted jobs.
+	Deployment *Deployment
+}
+
+// Deployment is a GitHub deployment environment.
+type Deployment struct {
+	// Environment is the name of the environment, e.g. "lab-esp32".
+	Environment string
+	// EnvironmentURL is the URL of the deployed environment, optional.
+	EnvironmentURL string
+	// Production marks the environment as a production one.
+	Production bool
+}
+
+// Cross is a cross compilation toolchain.
+type Cross struct {
+	// GOOS, GOARCH and GOARM are the target, e.g. "linux", "arm" and "7".
+	GOOS   string
+	GOARCH string
+	GOARM  string
+	// CGO sets CGO_ENABLED=1, or CGO_ENABLED=0 when false.
+	CGO bool
+	// CC and CXX are the cross compilers, e.g. "arm-linux-gnueabihf-gcc".
+	CC  string
+	CXX string
+	// Sysroot is the target's root filesystem with its headers and libraries,
+	// passed as --sysroot to the compiler and the linker.
+	Sysroot string
+}
+
+// Flash is a firmware to flash with one of the supported tools.
+type Flash struct {
+	// Tool is one of "esptool", "openocd" or "picotool".
+	Tool string
+	// Target is the chip for esptool, e.g. "esp32", or the configuration file
+	// for openocd, e.g. "board/st_nucleo_f4.cfg". It is not used by picotool.
+	Target string
+	// Port is the serial port for esptool, e.g. "/dev/ttyUSB0".
+	Port string
+	// Binary is the firmware to flash, relative to Dir.
+	Binary string
+	// Address is where to write the binary, e.g. "0x10000". Defaults to 0x0
+	// for esptool; optional for the others.
+	Address string
+	// Retries is the number of retries on failure. Defaults to 2; -1 disables
+	// retries.
+	Retries int
+}
+
+// TinyGo is a tinygo command to run, from the PATH or as installed via
+// WorkerConfig.TinyGoVersion.
+type TinyGo struct {
+	// Command is one of "build", "flash" or "test".
+	Command string
+	// Target is the board, e.g. "pico" or "arduino-nano33".
+	Target string
+	// Package to build, defaults to ".".
+	Package string
+	// Output is the file to write for "build". Defaults to "firmware.hex".
+	Output string
+	// Port is the serial port to use for "flash". tinygo detects it when
+	// empty.
+	Port string
+	// Retries is the number of retries of "flash" on failure. Defaults to 2;
+	// -1 disables retries.
+	Retries int
+}
+
+// Devices are the hardware a project needs attached to the worker.
+type Devices struct {
+	// USB are the "vid:pid" of USB devices, e.g. "2e8a:0003".
+	USB []string
+	// Paths are files that must exist, e.g. "/dev/spidev0.0".
+	Paths []string
+	// I2C are the "bus:addr" of I²C devices, e.g. "1:0x48", as detected by
+	// i2cdetect.
+	I2C []string
+}
+
+// SerialPort is a serial port to capture.
+type SerialPort struct {
+	// Device is the path of the port, e.g. "/dev/ttyUSB0".
+	Device string
+	// Baud is the speed to configure via stty, e.g. 115200. The port is used
+	// as is when 0.
+	Baud int
+}
+
+// Reset power cycles a device attached to the worker, via a GPIO driving a
+// relay or a switchable USB hub port.
+type Reset struct {
+	// GPIOChip and GPIOLine is the GPIO driving the relay, e.g. "gpiochip0"
+	// and 17. It is set to GPIOValue for Duration via gpioset, then released.
+	GPIOChip  string
+	GPIOLine  int
+	GPIOValue int
+	// USBHub and USBPort is the USB hub port to power cycle via uhubctl, e.g.
+	// "1-1" and 2.
+	USBHub  string
+	USBPort int
+	// Duration is how long the device is kept off. Defaults to 1s.
+	Duration time.Duration
+	// Wait is how long to wait after the power is restored, e.g. to let the
+	// device boot.
+	Wait time.Duration
 }
 
 // ProjectConfig is a configuration file found in a project as ".gohci.yml" in
@@ -59,4 +568,33 @@ type ProjectWorkerConfig struct {
 type ProjectConfig struct {
 	Version int                   // Current 1
 	Workers []ProjectWorkerConfig //
+	// Language is "go", the default, or "none" for projects not written in Go,
+	// e.g. tested with a Makefile. With "none", GOPATH and the worker's Go
+	// settings are not set for the checks and there's no default check.
+	Language string
+	// SkipDrafts skips draft PRs. They are tested once marked as ready for
+	// review.
+	SkipDrafts bool
+	// GoPrivate is a comma separated list of module path patterns, e.g.
+	// "github.com/myorg/*", set as GOPRIVATE and GONOSUMDB for the checks. When
+	// the worker has a ModuleToken, git is configured to use it to fetch from
+	// github.com.
+	GoPrivate string
+	// BreakageIssues creates an issue when a push to the default branch fails,
+	// mentioning the author and committer. Subsequent failures are added as
+	// comments to the open issue instead of creating new ones. The issue is
+	// closed once the default branch passes again. This requires
+	// the worker's OAuth2 token to have the "public_repo" or "repo" scope.
+	BreakageIssues bool
+	// ChangedPackages runs the checks of PRs only on the packages affected by
+	// the PR: the ones with a modified file and the ones depending on them. In
+	// the checks, "./..." is replaced with these packages and a check is
+	// skipped when none is affected. A change to go.mod or go.sum tests
+	// everything. The default branch is always fully tested.
+	ChangedPackages bool
+	// BisectCheck is the 1-based index of a fast check, in the checks used by
+	// the worker, to bisect with when a push to the default branch fails while
+	// the previous one passed. The first bad commit is added to the gist and to
+	// the breakage issue. 0 disables bisection.
+	BisectCheck int
 }
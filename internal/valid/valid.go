@@ -0,0 +1,46 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+// Package valid validates the values shared by the configuration and the
+// commands.
+package valid
+
+import "strings"
+
+// Ref returns true if s is a reasonable git ref name.
+//
+// This is stricter than git-check-ref-format, since the value ends up on the
+// command line.
+func Ref(s string) bool {
+	return s != "" && strings.Trim(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_./+") == "" &&
+		!strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "/") && !strings.HasSuffix(s, "/") &&
+		!strings.Contains(s, "..") && !strings.Contains(s, "//") && !strings.HasSuffix(s, ".lock")
+}
+
+// OrgRepo returns true if s is "org/repo".
+func OrgRepo(s string) bool {
+	parts := strings.SplitN(s, "/", 2)
+	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
+}
+
+// SSHKey returns true if k looks like an SSH public key.
+func SSHKey(k string) bool {
+	return strings.HasPrefix(k, "ssh-") || strings.HasPrefix(k, "ecdsa-") || strings.HasPrefix(k, "sk-")
+}
+
+// SigningKey returns true if k is a SSH public key or a GPG v4 or v5
+// fingerprint.
+func SigningKey(k string) bool {
+	if SSHKey(k) {
+		return len(strings.Fields(k)) >= 2
+	}
+	fp := NormalizeFingerprint(k)
+	return (len(fp) == 40 || len(fp) == 64) && strings.Trim(fp, "0123456789ABCDEF") == ""
+}
+
+// NormalizeFingerprint removes the spaces gpg adds when printing a
+// fingerprint.
+func NormalizeFingerprint(k string) string {
+	return strings.ToUpper(strings.ReplaceAll(k, " ", ""))
+}
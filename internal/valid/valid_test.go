@@ -0,0 +1,33 @@
+// Copyright 2026 Marc-Antoine Ruel. All rights reserved.
+// Use of this source code is governed under the Apache License, Version 2.0
+// that can be found in the LICENSE file.
+
+package valid
+
+import "testing"
+
+func TestRef(t *testing.T) {
+	for _, s := range []string{"main", "refs/heads/feature/x", "v1.2.3", "a+b_c-d"} {
+		if !Ref(s) {
+			t.Fatal(s)
+		}
+	}
+	for _, s := range []string{"", "-x", "/x", "x/", "a..b", "a//b", "x.lock", "a b", "a;b"} {
+		if Ref(s) {
+			t.Fatal(s)
+		}
+	}
+}
+
+func TestSigningKey(t *testing.T) {
+	for _, k := range []string{"ssh-ed25519 AAAAC3Nza", "0123 4567 89AB CDEF 0123  4567 89ab cdef 0123 4567"} {
+		if !SigningKey(k) {
+			t.Fatal(k)
+		}
+	}
+	for _, k := range []string{"", "ssh-ed25519", "0123456789ABCDEF", "hello"} {
+		if SigningKey(k) {
+			t.Fatal(k)
+		}
+	}
+}